@@ -3,6 +3,7 @@ package app
 import (
 	"context"
 	"fmt"
+	"slices"
 	"time"
 
 	"github.com/redis/go-redis/v9"
@@ -12,37 +13,134 @@ import (
 	"gorm.io/gorm"
 	"gorm.io/gorm/logger"
 
+	"knowledgeleaf/database"
+	"knowledgeleaf/externalapi/wikipedia"
+	"knowledgeleaf/knowledgebase"
 	"knowledgeleaf/repository"
 )
 
 type Configuration struct {
-	Port                   int           `env:"PORT,default=4000"`
-	AllowedOrigins         []string      `env:"ALLOWED_ORIGINS,default=http://localhost"`
-	RedisDSN               string        `env:"REDIS_DSN,default=redis://localhost:6379"`
-	UseRedis               bool          `env:"USE_REDIS,default=false"`
-	RequestTimeout         time.Duration `env:"REQUEST_TIMEOUT,default=30s"`
-	ScheduledLoaderTimeout time.Duration `env:"SCHEDULED_LOADER_TIMEOUT,default=300s"`
-	PostgresHost           string        `env:"POSTGRES_HOST,default=localhost"`
-	PostgresPort           int           `env:"POSTGRES_PORT,default=5432"`
-	PostgresUser           string        `env:"POSTGRES_USER,default=knowledge_leaf"`
-	PostgresPassword       string        `env:"POSTGRES_PASSWORD"`
-	PostgresDatabase       string        `env:"POSTGRES_DATABASE,default=knowledge_leaf"`
-	PostgresEnabled        bool          `env:"POSTGRES_ENABLED,default=false"`
+	Port                       int            `env:"PORT,default=4000"`
+	AllowedOrigins             []string       `env:"ALLOWED_ORIGINS,default=http://localhost"`
+	RedisDSN                   string         `env:"REDIS_DSN,default=redis://localhost:6379"`
+	UseRedis                   bool           `env:"USE_REDIS,default=false"`
+	RequestTimeout             time.Duration  `env:"REQUEST_TIMEOUT,default=30s"`
+	ScheduledLoaderTimeout     time.Duration  `env:"SCHEDULED_LOADER_TIMEOUT,default=300s"`
+	PostgresHost               string         `env:"POSTGRES_HOST,default=localhost"`
+	PostgresPort               int            `env:"POSTGRES_PORT,default=5432"`
+	PostgresUser               string         `env:"POSTGRES_USER,default=knowledge_leaf"`
+	PostgresPassword           string         `env:"POSTGRES_PASSWORD"`
+	PostgresDatabase           string         `env:"POSTGRES_DATABASE,default=knowledge_leaf"`
+	PostgresEnabled            bool           `env:"POSTGRES_ENABLED,default=false"`
+	ArticleMaxAge              time.Duration  `env:"ARTICLE_MAX_AGE,default=168h"`
+	PrefetchPoolSize           int            `env:"PREFETCH_POOL_SIZE,default=10"`
+	PrefetchWorkers            int            `env:"PREFETCH_WORKERS,default=2"`
+	TriviaMaxCount             int            `env:"TRIVIA_MAX_COUNT,default=10"`
+	TriviaConcurrency          int            `env:"TRIVIA_CONCURRENCY,default=4"`
+	DisambiguationPolicy       PagePolicy     `env:"DISAMBIGUATION_POLICY,default=allow"`
+	ListPagePolicy             PagePolicy     `env:"LIST_PAGE_POLICY,default=allow"`
+	RedirectPolicy             PagePolicy     `env:"REDIRECT_POLICY,default=allow"`
+	FilterMinExtractLength     int            `env:"FILTER_MIN_EXTRACT_LENGTH,default=0"`
+	FilterRequireImage         bool           `env:"FILTER_REQUIRE_IMAGE,default=false"`
+	FilterBlockedTitlePatterns []string       `env:"FILTER_BLOCKED_TITLE_PATTERNS,delimiter=;"`
+	FilterBlockedCategories    []string       `env:"FILTER_BLOCKED_CATEGORIES,delimiter=;"`
+	QuizSecret                 string         `env:"QUIZ_SECRET"`
+	QuizTokenTTL               time.Duration  `env:"QUIZ_TOKEN_TTL,default=1h"`
+	SessionSeenTTL             time.Duration  `env:"SESSION_SEEN_TTL,default=24h"`
+	TitleSelection             TitleSelection `env:"TITLE_SELECTION,default=uniform"`
+	PopularityExponent         float64        `env:"POPULARITY_EXPONENT,default=1.0"`
+	PageviewsDumpPath          string         `env:"PAGEVIEWS_DUMP_PATH"`
+	LoaderSkipTitles           bool           `env:"LOADER_SKIP_TITLES,default=false"`
+	TitleStore                 TitleStore     `env:"TITLE_STORE"`
+	SQLitePath                 string         `env:"SQLITE_PATH,default=knowledgeleaf.db"`
+	KnowledgeBaseDir           string         `env:"KNOWLEDGEBASE_DIR"`
+	AdminToken                 string         `env:"ADMIN_TOKEN"`
+	TitleCountRefreshInterval  time.Duration  `env:"TITLE_COUNT_REFRESH_INTERVAL,default=5m"`
+	Languages                  []string       `env:"LANGUAGES,default=en"`
 }
 
+// TitleStore is the backend random titles are selected from.
+type TitleStore string
+
+const (
+	// TitleStoreEmbedded serves the title lists of KNOWLEDGEBASE_DIR from memory,
+	// or the sample titles embedded in the binary.
+	TitleStoreEmbedded TitleStore = "embedded"
+	// TitleStoreRedis serves the titles loaded into Redis.
+	TitleStoreRedis TitleStore = "redis"
+	// TitleStorePostgres serves the titles loaded into Postgres.
+	TitleStorePostgres TitleStore = "postgres"
+	// TitleStoreSQLite serves the titles loaded into the SQLite file at SQLITE_PATH.
+	TitleStoreSQLite TitleStore = "sqlite"
+)
+
+// DefaultLanguage is the Wikipedia edition language served when requests do not specify one.
+func (c Configuration) DefaultLanguage() string {
+	return c.Languages[0]
+}
+
+// titleStore returns the configured title store, defaulting to the enabled database.
+func (c Configuration) titleStore() TitleStore {
+	switch {
+	case c.TitleStore != "":
+		return c.TitleStore
+	case c.PostgresEnabled:
+		return TitleStorePostgres
+	case c.UseRedis:
+		return TitleStoreRedis
+	default:
+		return TitleStoreEmbedded
+	}
+}
+
+// TitleSelection is the strategy used to pick random titles.
+type TitleSelection string
+
+const (
+	// TitleSelectionUniform gives every title the same probability.
+	TitleSelectionUniform TitleSelection = "uniform"
+	// TitleSelectionPopularity samples titles weighted by their page views raised to PopularityExponent.
+	// Weights are computed by the loader, which has to run again for a new exponent to apply.
+	// Higher exponents favor famous articles, exponents below one favor the long tail.
+	TitleSelectionPopularity TitleSelection = "popularity"
+)
+
+// PagePolicy controls how pages that are not regular articles are served as trivia.
+type PagePolicy string
+
+const (
+	// PagePolicyAllow serves the page as is.
+	PagePolicyAllow PagePolicy = "allow"
+	// PagePolicySkip selects another title instead.
+	PagePolicySkip PagePolicy = "skip"
+	// PagePolicyExpand serves one of the articles a disambiguation page links to.
+	PagePolicyExpand PagePolicy = "expand"
+)
+
 type App struct {
 	Cfg                Configuration
 	RedisClient        *redis.Client
 	Logger             *zap.Logger
 	PostgresConnection *gorm.DB
-	Repository         repository.Repository
+	// Titles holds the title store of each served language
+	Titles     map[string]repository.TitleStore
+	Categories repository.CategoryRepository
+	Articles   repository.ArticleRepository
+	Stats      repository.StatsRepository
+	Sessions   repository.SessionRepository
+	// FeedCache shares the Wikipedia feed responses across instances, nil without Redis
+	FeedCache repository.FeedCache
 }
 
 func New() (App, func() error, error) {
 	app := App{}
 	cfg := Configuration{}
 	envconfig.MustProcess(context.Background(), &cfg)
+	cfg.TitleStore = cfg.titleStore()
 	app.Cfg = cfg
+	if err := cfg.validate(); err != nil {
+		return app, nil, err
+	}
 
 	appLogger, _ := zap.NewProduction()
 	app.Logger = appLogger
@@ -54,6 +152,7 @@ func New() (App, func() error, error) {
 			return app, nil, err
 		}
 		app.RedisClient = rc
+		app.FeedCache = repository.NewRedisFeedCache(rc)
 	}
 	if app.Cfg.PostgresEnabled {
 		dsn := fmt.Sprintf("user=%s password=%s host=%s port=%d dbname=%s",
@@ -71,7 +170,47 @@ func New() (App, func() error, error) {
 			return app, nil, err
 		}
 		app.PostgresConnection = db
-		app.Repository = repository.NewPostgresRepository(db)
+		app.Categories = repository.NewPostgresCategoryRepository(db, cfg.DefaultLanguage())
+		app.Articles = repository.NewPostgresArticleRepository(db)
+		app.Stats = repository.NewPostgresStatsRepository(db)
+		app.Sessions = repository.NewPostgresSessionRepository(db, cfg.SessionSeenTTL)
+	} else if app.RedisClient != nil {
+		app.Stats = repository.NewRedisStatsRepository(app.RedisClient)
+		app.Sessions = repository.NewRedisSessionRepository(app.RedisClient, cfg.SessionSeenTTL)
+	} else {
+		app.Sessions = repository.NewMemorySessionRepository(cfg.SessionSeenTTL)
+	}
+
+	app.Titles = make(map[string]repository.TitleStore, len(cfg.Languages))
+	switch cfg.TitleStore {
+	case TitleStorePostgres:
+		for _, lang := range cfg.Languages {
+			app.Titles[lang] = repository.NewPostgresTitleStore(app.PostgresConnection, lang)
+		}
+	case TitleStoreRedis:
+		for _, lang := range cfg.Languages {
+			app.Titles[lang] = repository.NewRedisTitleStore(app.RedisClient, lang)
+		}
+	case TitleStoreSQLite:
+		db, err := database.OpenSQLite(cfg.SQLitePath)
+		if err != nil {
+			return app, nil, err
+		}
+		for _, lang := range cfg.Languages {
+			app.Titles[lang] = repository.NewSQLiteTitleStore(db, lang)
+		}
+	case TitleStoreEmbedded:
+		// The title lists hold the titles of the default language only
+		titles, err := knowledgebase.Titles(cfg.KnowledgeBaseDir)
+		if err != nil && cfg.KnowledgeBaseDir != "" {
+			app.Logger.Warn("loading knowledge base failed - using the embedded samples", zap.Error(err))
+			titles, err = knowledgebase.Samples()
+		}
+		if err != nil {
+			return app, nil, err
+		}
+		app.Logger.Info(fmt.Sprintf("loaded %d titles", len(titles)))
+		app.Titles[cfg.DefaultLanguage()] = repository.NewMemoryTitleStore(titles)
 	}
 
 	return app, func() error {
@@ -79,6 +218,76 @@ func New() (App, func() error, error) {
 	}, nil
 }
 
+// TitlesFor returns the title store of a language, or nil when the language is not served.
+func (a App) TitlesFor(lang string) repository.TitleStore {
+	return a.Titles[lang]
+}
+
+// DefaultTitles returns the title store of the default language.
+func (a App) DefaultTitles() repository.TitleStore {
+	return a.Titles[a.Cfg.DefaultLanguage()]
+}
+
+func (c Configuration) validate() error {
+	if len(c.Languages) == 0 {
+		return fmt.Errorf("LANGUAGES must list at least one language")
+	}
+	for _, lang := range c.Languages {
+		if !wikipedia.ValidLanguage(lang) {
+			return fmt.Errorf("invalid LANGUAGES value %q", lang)
+		}
+	}
+	policies := []struct {
+		name    string
+		policy  PagePolicy
+		allowed []PagePolicy
+	}{
+		{"DISAMBIGUATION_POLICY", c.DisambiguationPolicy, []PagePolicy{PagePolicyAllow, PagePolicySkip, PagePolicyExpand}},
+		{"LIST_PAGE_POLICY", c.ListPagePolicy, []PagePolicy{PagePolicyAllow, PagePolicySkip}},
+		{"REDIRECT_POLICY", c.RedirectPolicy, []PagePolicy{PagePolicyAllow, PagePolicySkip}},
+	}
+	for _, p := range policies {
+		if !slices.Contains(p.allowed, p.policy) {
+			return fmt.Errorf("invalid %s value %q, expected one of %v", p.name, p.policy, p.allowed)
+		}
+	}
+	switch c.TitleStore {
+	case TitleStoreEmbedded:
+		// The title lists hold the titles of a single language
+		if len(c.Languages) > 1 {
+			return fmt.Errorf("TITLE_STORE %q serves a single language, got LANGUAGES %v", c.TitleStore, c.Languages)
+		}
+	case TitleStoreRedis:
+		if !c.UseRedis {
+			return fmt.Errorf("TITLE_STORE %q requires USE_REDIS", c.TitleStore)
+		}
+	case TitleStorePostgres:
+		if !c.PostgresEnabled {
+			return fmt.Errorf("TITLE_STORE %q requires POSTGRES_ENABLED", c.TitleStore)
+		}
+	case TitleStoreSQLite:
+		if c.SQLitePath == "" {
+			return fmt.Errorf("TITLE_STORE %q requires SQLITE_PATH", c.TitleStore)
+		}
+	default:
+		return fmt.Errorf("invalid TITLE_STORE value %q", c.TitleStore)
+	}
+	switch c.TitleSelection {
+	case TitleSelectionUniform:
+	case TitleSelectionPopularity:
+		if c.TitleStore != TitleStorePostgres && c.TitleStore != TitleStoreSQLite {
+			return fmt.Errorf("TITLE_SELECTION %q requires TITLE_STORE %q or %q",
+				c.TitleSelection, TitleStorePostgres, TitleStoreSQLite)
+		}
+		if c.PopularityExponent < 0 {
+			return fmt.Errorf("invalid POPULARITY_EXPONENT value %v, expected a non-negative number", c.PopularityExponent)
+		}
+	default:
+		return fmt.Errorf("invalid TITLE_SELECTION value %q", c.TitleSelection)
+	}
+	return nil
+}
+
 func newRedisClient(dsn string) (*redis.Client, error) {
 	opts, err := redis.ParseURL(dsn)
 	if err != nil {
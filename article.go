@@ -0,0 +1,220 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"math/rand"
+	"strings"
+	"time"
+	"unicode"
+	"unicode/utf8"
+
+	"go.uber.org/zap"
+	"golang.org/x/sync/errgroup"
+
+	"knowledgeleaf/app"
+	"knowledgeleaf/database"
+	"knowledgeleaf/externalapi/wikipedia"
+	"knowledgeleaf/repository"
+)
+
+const articleTypeDisambiguation = "disambiguation"
+
+// maxTitleLength is the maximum length of a Wikipedia title, in bytes.
+const maxTitleLength = 255
+
+// errSkippedPage is returned when a page policy rejects the resolved page.
+var errSkippedPage = errors.New("page skipped by policy")
+
+// fetchArticle resolves the summary and categories of a title of the Wikipedia edition in the given
+// language, the default language when empty. Stored articles fetched within the configured
+// staleness window are served without calling the Wikipedia APIs.
+func fetchArticle(ctx context.Context, triviaBackend *RandomTriviaBackend, lang string, title string) (WikiSummary, error) {
+	logger := app.LoggerFromContext(ctx)
+	lang = triviaBackend.language(lang)
+	articles := triviaBackend.application.Articles
+	maxAge := triviaBackend.application.Cfg.ArticleMaxAge
+	if articles != nil && maxAge > 0 {
+		article, err := articles.FindArticle(ctx, lang, title)
+		switch {
+		case err == nil && time.Since(article.FetchedAt) < maxAge:
+			return newWikiSummary(article), nil
+		case err != nil && !errors.Is(err, repository.ErrNotFound):
+			logger.Warn("reading stored article failed", zap.Error(err), zap.String("title", title))
+		}
+	}
+
+	var (
+		summaryResp wikipedia.RestV1SummaryResponse
+		categories  []string
+	)
+	client := wikipedia.NewLanguageClient(lang)
+	group, groupCtx := errgroup.WithContext(ctx)
+	group.Go(func() error {
+		summary, err := client.GetSummary(groupCtx, title)
+		if err != nil {
+			return err
+		}
+		summaryResp = summary
+		return nil
+	})
+	group.Go(func() error {
+		var err error
+		categories, err = client.Categories(groupCtx, title)
+		return err
+	})
+	if err := group.Wait(); err != nil {
+		return WikiSummary{}, err
+	}
+
+	article := newArticle(lang, title, summaryResp, categories)
+	if articles != nil && maxAge > 0 {
+		if err := articles.SaveArticle(ctx, &article); err != nil {
+			logger.Warn("storing article failed", zap.Error(err), zap.String("title", title))
+		}
+	}
+	if triviaBackend.application.Categories != nil && lang == triviaBackend.application.Cfg.DefaultLanguage() {
+		if err := triviaBackend.application.Categories.AddTitleCategories(ctx, title, categories); err != nil {
+			logger.Warn("storing title categories failed", zap.Error(err), zap.String("title", title))
+		}
+	}
+	return newWikiSummary(article), nil
+}
+
+func newArticle(lang string, title string, summary wikipedia.RestV1SummaryResponse, categories []string) database.Article {
+	if categories == nil {
+		// The categories column does not accept NULL values
+		categories = []string{}
+	}
+	return database.Article{
+		Lang:         lang,
+		Title:        title,
+		DisplayTitle: summary.Title,
+		Type:         summary.Type,
+		PageID:       summary.Pageid,
+		WikibaseItem: summary.WikibaseItem,
+		Extract:      summary.Extract,
+		Description:  summary.Description,
+		URL:          summary.ContentUrls.Desktop.Page,
+		Thumbnail: database.ArticleImage{
+			URL:    summary.Thumbnail.Source,
+			Width:  summary.Thumbnail.Width,
+			Height: summary.Thumbnail.Height,
+		},
+		OriginalImage: database.ArticleImage{
+			URL:    summary.Originalimage.Source,
+			Width:  summary.Originalimage.Width,
+			Height: summary.Originalimage.Height,
+		},
+		Categories: categories,
+		Revision:   summary.Revision,
+		FetchedAt:  time.Now().UTC(),
+	}
+}
+
+func newWikiSummary(article database.Article) WikiSummary {
+	return WikiSummary{
+		Title:   article.DisplayTitle,
+		Summary: article.Extract,
+		Type:    article.Type,
+		Metadata: WikiSummaryMetadata{
+			Description: article.Description,
+			URL:         article.URL,
+			Image: Image{
+				URL:    article.Thumbnail.URL,
+				Width:  article.Thumbnail.Width,
+				Height: article.Thumbnail.Height,
+			},
+		},
+		Categories: article.Categories,
+		AppLinkURL: articleAppLinkURL(article.Lang, article.DisplayTitle),
+		fetchedAt:  article.FetchedAt,
+		lang:       article.Lang,
+	}
+}
+
+// applyPagePolicy enforces the configured policies for pages that are not regular articles.
+// The summary of title is either returned as is, replaced by an article a disambiguation page
+// links to, or rejected with errSkippedPage.
+func applyPagePolicy(
+	ctx context.Context,
+	triviaBackend *RandomTriviaBackend,
+	query TitleQuery,
+	title string,
+	summary WikiSummary,
+) (WikiSummary, error) {
+	cfg := triviaBackend.application.Cfg
+	switch {
+	case summary.Type == articleTypeDisambiguation:
+		switch cfg.DisambiguationPolicy {
+		case app.PagePolicySkip:
+			return WikiSummary{}, errSkippedPage
+		case app.PagePolicyExpand:
+			return expandDisambiguation(ctx, triviaBackend, query, title)
+		}
+	case isListPage(summary.Title):
+		if cfg.ListPagePolicy == app.PagePolicySkip {
+			return WikiSummary{}, errSkippedPage
+		}
+	case isRedirect(title, summary.Title):
+		if cfg.RedirectPolicy == app.PagePolicySkip {
+			return WikiSummary{}, errSkippedPage
+		}
+	}
+	return summary, nil
+}
+
+// expandDisambiguation resolves one of the candidate articles of a disambiguation page.
+func expandDisambiguation(
+	ctx context.Context,
+	triviaBackend *RandomTriviaBackend,
+	query TitleQuery,
+	title string,
+) (WikiSummary, error) {
+	candidates, err := wikipedia.NewLanguageClient(triviaBackend.language(query.Lang)).
+		DisambiguationCandidates(ctx, title)
+	if err != nil {
+		return WikiSummary{}, err
+	}
+	if len(candidates) == 0 {
+		return WikiSummary{}, errSkippedPage
+	}
+	var candidate string
+	if seed, ok := query.seededValue(); ok {
+		candidate = candidates[seed%uint64(len(candidates))]
+	} else {
+		candidate = candidates[rand.Intn(len(candidates))]
+	}
+	app.LoggerFromContext(ctx).Info("expanding disambiguation page",
+		zap.String("title", title), zap.String("candidate", candidate))
+	summary, err := fetchArticle(ctx, triviaBackend, query.Lang, strings.ReplaceAll(candidate, " ", "_"))
+	if err != nil {
+		return WikiSummary{}, err
+	}
+	if summary.Type == articleTypeDisambiguation {
+		return WikiSummary{}, errSkippedPage
+	}
+	return summary, nil
+}
+
+// canonicalTitle converts a title to the form of Wikipedia page URLs: underscore separated,
+// with an uppercase first letter. It reports whether the title is valid.
+func canonicalTitle(title string) (string, bool) {
+	title = strings.Join(strings.Fields(title), "_")
+	r, size := utf8.DecodeRuneInString(title)
+	if r == utf8.RuneError || len(title) > maxTitleLength || strings.ContainsAny(title, "#<>[]{}|") {
+		return "", false
+	}
+	return string(unicode.ToUpper(r)) + title[size:], true
+}
+
+func isListPage(title string) bool {
+	title = titleKey(title)
+	return strings.HasPrefix(title, "List of ") || strings.HasPrefix(title, "Lists of ")
+}
+
+// isRedirect reports whether the requested title resolved to a page with a different title.
+// Titles are case-sensitive, so titles differing only in case are redirects as well.
+func isRedirect(requested, resolved string) bool {
+	return titleKey(requested) != titleKey(resolved)
+}
@@ -0,0 +1,61 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"go.uber.org/zap"
+
+	"knowledgeleaf/app"
+	"knowledgeleaf/externalapi/wikipedia"
+	"knowledgeleaf/repository"
+)
+
+// categoryMembersMaxAge is how long the stored members of a category are used before being fetched again.
+const categoryMembersMaxAge = 7 * 24 * time.Hour
+
+// loadCategoryMembers stores the members of the categories that were not fetched within categoryMembersMaxAge,
+// so that the category filter selects among all the articles of a category and not only the ones already served.
+// Failures are logged, the titles already stored for the category remain available.
+func (b *RandomTriviaBackend) loadCategoryMembers(ctx context.Context, categories []string) {
+	logger := app.LoggerFromContext(ctx)
+	for _, category := range categories {
+		loadedAt, err := b.application.Categories.MembersLoadedAt(ctx, category)
+		switch {
+		case err == nil && time.Since(loadedAt) < categoryMembersMaxAge:
+			continue
+		case err != nil && !errors.Is(err, repository.ErrNotFound):
+			logger.Warn("reading category load time failed", zap.Error(err), zap.String("category", category))
+			continue
+		}
+		// Concurrent requests for the same category share one fetch, which outlives the request that started it
+		ch := b.categoryGroup.DoChan(category, func() (any, error) {
+			fetchCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), b.application.Cfg.RequestTimeout)
+			defer cancel()
+			return nil, b.storeCategoryMembers(fetchCtx, category)
+		})
+		select {
+		case <-ctx.Done():
+			return
+		case res := <-ch:
+			if res.Err != nil {
+				logger.Warn("loading category members failed", zap.Error(res.Err), zap.String("category", category))
+			}
+		}
+	}
+}
+
+func (b *RandomTriviaBackend) storeCategoryMembers(ctx context.Context, category string) error {
+	lang := b.application.Cfg.DefaultLanguage()
+	members, err := wikipedia.NewLanguageClient(lang).AllCategoryMembers(ctx, category)
+	if err != nil {
+		return err
+	}
+	titles := make([]string, 0, len(members))
+	for _, member := range members {
+		// Titles are stored in their URL form, as loaded from the dumps
+		titles = append(titles, seenKey(member))
+	}
+	return b.application.Categories.AddCategoryMembers(ctx, category, titles)
+}
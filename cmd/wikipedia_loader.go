@@ -6,11 +6,14 @@ import (
 	"maps"
 	"slices"
 	"strings"
+	"time"
 
 	"go.uber.org/zap"
 
 	"knowledgeleaf/app"
+	"knowledgeleaf/database"
 	"knowledgeleaf/externalapi/wikipedia"
+	"knowledgeleaf/repository"
 )
 
 func main() {
@@ -24,10 +27,62 @@ func main() {
 		}
 	}()
 
+	if application.Cfg.TitleStore == app.TitleStoreEmbedded {
+		application.Logger.Fatal("the loader requires TITLE_STORE to be redis, postgres or sqlite")
+	}
+
 	ctx, cancel := context.WithTimeout(context.Background(), application.Cfg.ScheduledLoaderTimeout)
 	defer cancel()
-	application.Logger.Info("fetching data from wikipedia")
-	scanner, onComplete, err := wikipedia.DownloadArticleDump(ctx)
+	startedAt := time.Now().UTC()
+
+	if application.Cfg.LoaderSkipTitles {
+		application.Logger.Info("skipping wikipedia article dump")
+	} else {
+		var titleCount int
+		for _, lang := range application.Cfg.Languages {
+			titles := application.TitlesFor(lang)
+			titleCount += loadTitles(ctx, application, lang, titles)
+			if notifier, ok := titles.(repository.TitleLoadNotifier); ok {
+				if err := notifier.NotifyLoaded(ctx); err != nil {
+					application.Logger.Error("announcing title load failed", zap.Error(err), zap.String("lang", lang))
+				}
+			}
+		}
+		if application.Stats != nil {
+			err := application.Stats.RecordLoaderRun(ctx, database.LoaderRun{
+				TitleCount:  int64(titleCount),
+				StartedAt:   startedAt,
+				CompletedAt: time.Now().UTC(),
+			})
+			if err != nil {
+				application.Logger.Error("recording loader run failed", zap.Error(err))
+			}
+		}
+	}
+
+	if application.Cfg.PageviewsDumpPath != "" {
+		for _, lang := range application.Cfg.Languages {
+			loadPageviews(ctx, application, lang, application.Cfg.PageviewsDumpPath)
+		}
+	}
+
+	// Selection weights cover the titles and page views loaded so far
+	for _, lang := range application.Cfg.Languages {
+		store, ok := application.TitlesFor(lang).(repository.WeightedTitleStore)
+		if !ok {
+			continue
+		}
+		if err := store.IndexWeights(ctx, application.Cfg.PopularityExponent); err != nil {
+			application.Logger.Fatal("indexing title weights failed", zap.Error(err), zap.String("lang", lang))
+		}
+		application.Logger.Info("title weights indexed", zap.String("lang", lang))
+	}
+}
+
+// loadTitles persists the titles of the latest article dump of a Wikipedia edition and returns their count.
+func loadTitles(ctx context.Context, application app.App, lang string, titles repository.TitleStore) int {
+	application.Logger.Info("fetching data from wikipedia", zap.String("lang", lang))
+	scanner, onComplete, err := wikipedia.DownloadArticleDump(ctx, lang)
 	if err != nil {
 		application.Logger.Fatal("wikipedia request failed", zap.Error(err))
 	}
@@ -54,17 +109,65 @@ func main() {
 	}
 	application.Logger.Info(
 		"wikipedia article dump retrieval completed",
+		zap.String("lang", lang),
 		zap.Int("total_titles", len(articleTitles)))
 
+	// Stores that support staging only expose the titles once all of them have been loaded
+	staged, isStaged := titles.(repository.StagedTitleStore)
 	allTitles := slices.Collect(maps.Keys(articleTitles))
 	for batch := range slices.Chunk(allTitles, 1000) {
 		index += len(batch)
-		if err := application.Repository.BulkCreate(ctx, batch); err != nil {
+		if isStaged {
+			err = staged.Stage(ctx, batch)
+		} else {
+			err = titles.Add(ctx, batch...)
+		}
+		if err != nil {
 			application.Logger.Fatal("persisting batch failed", zap.Error(err))
 		}
 		application.Logger.Info(fmt.Sprintf("created %d entries", index))
 
 	}
+	if isStaged {
+		if err := staged.Commit(ctx); err != nil {
+			application.Logger.Fatal("completing title load failed", zap.Error(err))
+		}
+	}
+	return len(allTitles)
+}
+
+// loadPageviews stores the view counts of a Wikipedia edition in a local pageviews dump
+// as the popularity score of its titles.
+func loadPageviews(ctx context.Context, application app.App, lang string, path string) {
+	store, ok := application.TitlesFor(lang).(repository.WeightedTitleStore)
+	if !ok {
+		application.Logger.Fatal("title store does not support page views",
+			zap.String("title_store", string(application.Cfg.TitleStore)))
+	}
+	application.Logger.Info("reading pageviews dump", zap.String("path", path), zap.String("lang", lang))
+	views, err := wikipedia.ReadPageviewsDump(path, wikipedia.PageviewsDomainCodes(lang))
+	if err != nil {
+		application.Logger.Fatal("error reading pageviews dump", zap.Error(err))
+	}
+	application.Logger.Info("pageviews dump read", zap.Int("total_titles", len(views)))
+
+	batch := make(map[string]int64, 1000)
+	var updated int
+	flush := func() {
+		if err := store.UpdatePageViews(ctx, batch); err != nil {
+			application.Logger.Fatal("persisting page views failed", zap.Error(err))
+		}
+		updated += len(batch)
+		clear(batch)
+		application.Logger.Info(fmt.Sprintf("updated page views of %d titles", updated))
+	}
+	for title, n := range views {
+		batch[title] = n
+		if len(batch) == 1000 {
+			flush()
+		}
+	}
+	flush()
 }
 
 func normalizeTitle(s string) (string, bool) {
@@ -0,0 +1,93 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"time"
+
+	"go.uber.org/zap"
+	"golang.org/x/sync/singleflight"
+
+	"knowledgeleaf/app"
+	"knowledgeleaf/repository"
+)
+
+// dailyArticles pins the article of the day once chosen, until the next UTC day boundary,
+// so that later changes of the title store do not change it during the day.
+type dailyArticles struct {
+	local  repository.FeedCache
+	shared repository.FeedCache
+	group  singleflight.Group
+	// timeout bounds the selection, which outlives the request that started it
+	timeout time.Duration
+}
+
+func newDailyArticles(application app.App) *dailyArticles {
+	return &dailyArticles{
+		local:   repository.NewMemoryFeedCache(),
+		shared:  application.FeedCache,
+		timeout: application.Cfg.RequestTimeout,
+	}
+}
+
+// Summaries returns the article of the UTC date of now, choosing it on the first request of the day.
+func (d *dailyArticles) Summaries(ctx context.Context, triviaBackend *RandomTriviaBackend, now time.Time) ([]WikiSummary, error) {
+	key := dailySeed(now)
+	b, err := d.local.Get(ctx, key)
+	if errors.Is(err, repository.ErrNotFound) {
+		ch := d.group.DoChan(key, func() (any, error) {
+			chooseCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), d.timeout)
+			defer cancel()
+			return d.choose(chooseCtx, triviaBackend, key, now)
+		})
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case res := <-ch:
+			if res.Err != nil {
+				return nil, res.Err
+			}
+			b, err = res.Val.([]byte), nil
+		}
+	}
+	if err != nil {
+		return nil, err
+	}
+	var summaries []WikiSummary
+	if err := json.Unmarshal(b, &summaries); err != nil {
+		return nil, err
+	}
+	return summaries, nil
+}
+
+// choose reads the pinned article from the shared cache, or selects it with the daily seed and pins it.
+func (d *dailyArticles) choose(ctx context.Context, triviaBackend *RandomTriviaBackend, key string, now time.Time) ([]byte, error) {
+	logger := app.LoggerFromContext(ctx)
+	now = now.UTC()
+	expiresAt := time.Date(now.Year(), now.Month(), now.Day()+1, 0, 0, 0, 0, time.UTC)
+	if d.shared != nil {
+		b, err := d.shared.Get(ctx, key)
+		switch {
+		case err == nil:
+			return b, d.local.Set(ctx, key, b, expiresAt)
+		case !errors.Is(err, repository.ErrNotFound):
+			logger.Warn("reading daily article failed", zap.Error(err), zap.String("key", key))
+		}
+	}
+
+	summaries, err := randomizeArticle(ctx, triviaBackend, TitleQuery{Seed: key})
+	if err != nil {
+		return nil, err
+	}
+	b, err := json.Marshal(summaries)
+	if err != nil {
+		return nil, err
+	}
+	if d.shared != nil {
+		if err := d.shared.Set(ctx, key, b, expiresAt); err != nil {
+			logger.Warn("pinning daily article failed", zap.Error(err), zap.String("key", key))
+		}
+	}
+	return b, d.local.Set(ctx, key, b, expiresAt)
+}
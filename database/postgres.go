@@ -3,9 +3,98 @@ package database
 import "time"
 
 type WikipediaTitle struct {
-	ID        string `gorm:"primaryKey"`
-	Title     string
-	NumericID int
+	ID string `gorm:"primaryKey"`
+	// Lang is the language code of the Wikipedia edition of the title
+	Lang  string
+	Title string
+	// Ordinal is the dense position of the title, from 1 up to the title count of its language
+	Ordinal int64
+	// PageViews is the popularity score of the title, taken from the pageviews dumps
+	PageViews int64
+	// WeightFrom and WeightTo bound the interval of the title within the cumulative selection
+	// weights of its language, nil for titles without page views
+	WeightFrom *float64
+	WeightTo   *float64
+	CreatedAt  time.Time
+	UpdatedAt  time.Time
+}
+
+// WikipediaTitleCounter is the row holding the highest title ordinal of a language,
+// along with the total selection weight of its titles.
+type WikipediaTitleCounter struct {
+	Lang        string `gorm:"primaryKey"`
+	Value       int64
+	TotalWeight float64
+}
+
+func (WikipediaTitleCounter) TableName() string {
+	return "wikipedia_title_counter"
+}
+
+type WikipediaTitleCategory struct {
+	Title     string `gorm:"primaryKey"`
+	Category  string `gorm:"primaryKey"`
+	CreatedAt time.Time
+}
+
+// WikipediaCategory records when the members of a category were last fetched from Wikipedia.
+type WikipediaCategory struct {
+	Category        string `gorm:"primaryKey"`
+	MembersLoadedAt time.Time
+}
+
+// RejectedTitle is a title excluded from selection because its article did not pass the quality filters.
+type RejectedTitle struct {
+	Lang      string `gorm:"primaryKey"`
+	Title     string `gorm:"primaryKey"`
+	Reason    string
+	CreatedAt time.Time
+}
+
+type ArticleImage struct {
+	URL    string
+	Width  int
+	Height int
+}
+
+// Article is a Wikipedia page summary along with its categories, as fetched from the Wikipedia APIs.
+type Article struct {
+	Lang          string `gorm:"primaryKey"`
+	Title         string `gorm:"primaryKey"`
+	DisplayTitle  string
+	Type          string
+	PageID        int
+	WikibaseItem  string
+	Extract       string
+	Description   string
+	URL           string
+	Thumbnail     ArticleImage `gorm:"embedded;embeddedPrefix:thumbnail_"`
+	OriginalImage ArticleImage `gorm:"embedded;embeddedPrefix:original_image_"`
+	Categories    []string     `gorm:"serializer:json"`
+	Revision      string
+	FetchedAt     time.Time
+	CreatedAt     time.Time
+	UpdatedAt     time.Time
+}
+
+type ArticleView struct {
+	Lang         string `gorm:"primaryKey"`
+	Title        string `gorm:"primaryKey"`
+	Views        int64
+	LastViewedAt time.Time
+}
+
+// LoaderRun records a completed execution of the Wikipedia loader.
+type LoaderRun struct {
+	ID          int64 `gorm:"primaryKey"`
+	TitleCount  int64
+	StartedAt   time.Time
+	CompletedAt time.Time
+}
+
+// SessionSeenTitle is a title served to an anonymous client session.
+type SessionSeenTitle struct {
+	SessionID string `gorm:"primaryKey"`
+	Title     string `gorm:"primaryKey"`
 	CreatedAt time.Time
-	UpdatedAt time.Time
 }
@@ -0,0 +1,76 @@
+package database
+
+import (
+	"io/fs"
+	"path"
+	"slices"
+	"strings"
+
+	"github.com/glebarez/sqlite"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+
+	"knowledgeleaf/migrations"
+)
+
+// SchemaMigration records an applied SQLite migration.
+type SchemaMigration struct {
+	Version string `gorm:"primaryKey"`
+}
+
+// OpenSQLite opens the database file at the given path, creating it when missing,
+// and applies the pending migrations.
+func OpenSQLite(dsn string) (*gorm.DB, error) {
+	db, err := gorm.Open(sqlite.Open(dsn), &gorm.Config{
+		Logger: logger.Default.LogMode(logger.Silent),
+	})
+	if err != nil {
+		return nil, err
+	}
+	conn, err := db.DB()
+	if err != nil {
+		return nil, err
+	}
+	// SQLite allows a single writer, sharing one connection avoids busy errors
+	conn.SetMaxOpenConns(1)
+	if err := migrateSQLite(db); err != nil {
+		return nil, err
+	}
+	return db, nil
+}
+
+func migrateSQLite(db *gorm.DB) error {
+	err := db.Exec("CREATE TABLE IF NOT EXISTS schema_migrations (version VARCHAR(255) PRIMARY KEY)").Error
+	if err != nil {
+		return err
+	}
+	var applied []string
+	if err := db.Model(&SchemaMigration{}).Pluck("version", &applied).Error; err != nil {
+		return err
+	}
+	files, err := fs.Glob(migrations.SQLite, "sqlite/*.up.sql")
+	if err != nil {
+		return err
+	}
+	slices.Sort(files)
+	for _, file := range files {
+		version := strings.TrimSuffix(path.Base(file), ".up.sql")
+		if slices.Contains(applied, version) {
+			continue
+		}
+		b, err := migrations.SQLite.ReadFile(file)
+		if err != nil {
+			return err
+		}
+		err = db.Transaction(func(tx *gorm.DB) error {
+			if err := tx.Exec(string(b)).Error; err != nil {
+				return err
+			}
+			return tx.Create(&SchemaMigration{Version: version}).Error
+		})
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,80 @@
+package wikipedia
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/georgepsarakis/go-httpclient"
+)
+
+// https://en.wikipedia.org/api/rest_v1/feed/featured/2024/12/01
+const restV1FeaturedEndpoint = "https://%s.wikipedia.org/api/rest_v1/feed/featured/%s"
+
+// Featured returns the featured content of a day: the featured article, the most read articles,
+// the picture of the day and the news. Editions only provide some of them, the others are left empty.
+func (c Client) Featured(ctx context.Context, date time.Time) (RestV1FeaturedResponse, error) {
+	resp, err := c.httpClient.Get(ctx, fmt.Sprintf(restV1FeaturedEndpoint, c.lang, date.Format("2006/01/02")))
+	if err != nil {
+		return RestV1FeaturedResponse{}, err
+	}
+	if resp.StatusCode != http.StatusOK {
+		if resp.StatusCode == http.StatusNotFound {
+			return RestV1FeaturedResponse{}, ErrNotFound
+		}
+		return RestV1FeaturedResponse{}, errors.New(resp.Status)
+	}
+	var v RestV1FeaturedResponse
+	if err := httpclient.DeserializeJSON(resp, &v); err != nil {
+		return RestV1FeaturedResponse{}, err
+	}
+	return v, nil
+}
+
+type RestV1FeaturedResponse struct {
+	// Tfa is today's featured article
+	Tfa      *RestV1SummaryResponse `json:"tfa"`
+	Mostread *struct {
+		Date     string `json:"date"`
+		Articles []struct {
+			RestV1SummaryResponse
+			Views int64 `json:"views"`
+			Rank  int   `json:"rank"`
+		} `json:"articles"`
+	} `json:"mostread"`
+	Image *struct {
+		Title     string `json:"title"`
+		Thumbnail struct {
+			Source string `json:"source"`
+			Width  int    `json:"width"`
+			Height int    `json:"height"`
+		} `json:"thumbnail"`
+		Image struct {
+			Source string `json:"source"`
+			Width  int    `json:"width"`
+			Height int    `json:"height"`
+		} `json:"image"`
+		FilePage    string `json:"file_page"`
+		Description struct {
+			Text string `json:"text"`
+			Lang string `json:"lang"`
+		} `json:"description"`
+		Artist struct {
+			Text string `json:"text"`
+		} `json:"artist"`
+		Credit struct {
+			Text string `json:"text"`
+		} `json:"credit"`
+		License struct {
+			Type string `json:"type"`
+			URL  string `json:"url"`
+		} `json:"license"`
+	} `json:"image"`
+	News []struct {
+		// Story is an HTML fragment
+		Story string                  `json:"story"`
+		Links []RestV1SummaryResponse `json:"links"`
+	} `json:"news"`
+}
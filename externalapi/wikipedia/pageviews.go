@@ -0,0 +1,66 @@
+package wikipedia
+
+import (
+	"bufio"
+	"compress/gzip"
+	"fmt"
+	"io"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// PageviewsDomainCodes returns the domain codes of the Wikipedia desktop and mobile sites
+// in the given language, in the pageviews dumps.
+func PageviewsDomainCodes(lang string) []string {
+	return []string{lang, lang + ".m"}
+}
+
+// ReadPageviewsDump aggregates the view counts per title of a local pageviews dump file,
+// for the given domain codes. Files ending in .gz are decompressed.
+//
+// The dumps are available at https://dumps.wikimedia.org/other/pageviews/ and consist of lines in the
+// format: domain_code page_title count_views total_response_size
+func ReadPageviewsDump(path string, domainCodes []string) (map[string]int64, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		_ = f.Close()
+	}()
+
+	var r io.Reader = f
+	if strings.HasSuffix(path, ".gz") {
+		gz, err := gzip.NewReader(f)
+		if err != nil {
+			return nil, err
+		}
+		defer func() {
+			_ = gz.Close()
+		}()
+		r = gz
+	}
+
+	views := make(map[string]int64, 1_000_000)
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	var line int
+	for scanner.Scan() {
+		line++
+		fields := strings.Fields(scanner.Text())
+		if len(fields) < 3 || !slices.Contains(domainCodes, fields[0]) {
+			continue
+		}
+		n, err := strconv.ParseInt(fields[2], 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid view count on line %d: %w", line, err)
+		}
+		views[fields[1]] += n
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	return views, nil
+}
@@ -10,6 +10,7 @@ import (
 	"net/http"
 	"net/url"
 	"os"
+	"regexp"
 	"slices"
 	"strings"
 	"time"
@@ -69,12 +70,22 @@ type RestV1SummaryResponse struct {
 
 const userAgent = "KnowledgeLeafBot/1.0 (https://knowledge-leaf.com)"
 
-// URL endpoints
-const restV1SummaryEndpoint = "https://en.wikipedia.org/api/rest_v1/page/summary"
-const titleCategoriesEndpoint = "https://en.wikipedia.org/w/api.php"
+// DefaultLanguage is the language of the Wikipedia edition used by NewClient.
+const DefaultLanguage = "en"
 
-// https://en.wikipedia.org/api/rest_v1/feed/onthisday/events/12/01
-const restV1OnThisDayEndpoint = "https://en.wikipedia.org/api/rest_v1/feed/onthisday/%s/%s/%s"
+// URL endpoints, formatted with the language code of the Wikipedia edition
+const restV1SummaryEndpoint = "https://%s.wikipedia.org/api/rest_v1/page/summary"
+const titleCategoriesEndpoint = "https://%s.wikipedia.org/w/api.php"
+
+// https://en.wikipedia.org/api/rest_v1/feed/onthisday/events/12/01, for any of the OnThisDayTypes
+const restV1OnThisDayEndpoint = "https://%s.wikipedia.org/api/rest_v1/feed/onthisday/%s/%s/%s"
+
+var languagePattern = regexp.MustCompile(`^[a-z]{2,3}(-[a-z]+)*$|^simple$`)
+
+// ValidLanguage reports whether lang has the form of a Wikipedia edition language code, such as "de" or "zh-yue".
+func ValidLanguage(lang string) bool {
+	return languagePattern.MatchString(lang)
+}
 
 var ErrNotFound = errors.New("not found")
 
@@ -84,14 +95,21 @@ var titleCategoriesBaseParameters = map[string]string{
 	"prop":    "categories",
 	"clprop":  "timestamp",
 	"clshow":  "!hidden",
-	"cllimit": "5",
+	"cllimit": "max",
 }
 
 type Client struct {
 	httpClient *httpclient.Client
+	lang       string
 }
 
+// NewClient returns a client of the English Wikipedia.
 func NewClient() *Client {
+	return NewLanguageClient(DefaultLanguage)
+}
+
+// NewLanguageClient returns a client of the Wikipedia edition in the given language.
+func NewLanguageClient(lang string) *Client {
 	c := httpclient.New()
 	c = c.WithDefaultHeaders(map[string]string{
 		"Content-Type": "application/json",
@@ -99,9 +117,14 @@ func NewClient() *Client {
 	})
 	return &Client{
 		httpClient: c,
+		lang:       lang,
 	}
 }
 
+func (c Client) actionAPIURL() string {
+	return fmt.Sprintf(titleCategoriesEndpoint, c.lang)
+}
+
 func (c Client) GetSummary(ctx context.Context, title string) (RestV1SummaryResponse, error) {
 	resp, err := c.httpClient.Get(ctx, c.summaryURL(title))
 	if err != nil {
@@ -122,12 +145,12 @@ func (c Client) GetSummary(ctx context.Context, title string) (RestV1SummaryResp
 }
 
 func (c Client) summaryURL(title string) string {
-	p, _ := url.JoinPath(restV1SummaryEndpoint, title)
+	p, _ := url.JoinPath(fmt.Sprintf(restV1SummaryEndpoint, c.lang), title)
 	return p
 }
 
 func (c Client) Categories(ctx context.Context, title string) ([]string, error) {
-	resp, err := c.httpClient.Get(ctx, titleCategoriesEndpoint, httpclient.WithQueryParameters(map[string]string{
+	resp, err := c.httpClient.Get(ctx, c.actionAPIURL(), httpclient.WithQueryParameters(map[string]string{
 		"titles": title,
 	}), httpclient.WithQueryParameters(titleCategoriesBaseParameters))
 	if err != nil {
@@ -140,7 +163,9 @@ func (c Client) Categories(ctx context.Context, title string) ([]string, error)
 	var categories []string
 	for _, page := range categoryResponse.Query.Pages {
 		for _, category := range page.Categories {
-			categories = append(categories, strings.TrimPrefix(category.Title, "Category:"))
+			// The namespace prefix is localized, such as "Kategorie:" in German
+			_, name, _ := strings.Cut(category.Title, ":")
+			categories = append(categories, name)
 		}
 	}
 	slices.Sort(categories)
@@ -162,64 +187,109 @@ type TitleCategoriesResponse struct {
 	} `json:"query"`
 }
 
-func (c Client) OnThisDay(ctx context.Context, date time.Time) (RestV1EventsOnThisDayResponse, error) {
-	resp, err := c.httpClient.Get(ctx, fmt.Sprintf(restV1OnThisDayEndpoint, "events", date.Format("01"), date.Format("02")))
+// On this day feed types
+const (
+	OnThisDayEvents   = "events"
+	OnThisDayBirths   = "births"
+	OnThisDayDeaths   = "deaths"
+	OnThisDayHolidays = "holidays"
+	OnThisDaySelected = "selected"
+)
+
+// OnThisDayTypes lists the on this day feed types.
+var OnThisDayTypes = []string{OnThisDayEvents, OnThisDayBirths, OnThisDayDeaths, OnThisDayHolidays, OnThisDaySelected}
+
+// OnThisDay returns the entries of an on this day feed type for the month and day of date.
+func (c Client) OnThisDay(ctx context.Context, feedType string, date time.Time) ([]RestV1OnThisDayEntry, error) {
+	resp, err := c.httpClient.Get(ctx, fmt.Sprintf(restV1OnThisDayEndpoint, c.lang, feedType, date.Format("01"), date.Format("02")))
 	if err != nil {
-		return RestV1EventsOnThisDayResponse{}, err
+		return nil, err
+	}
+	if resp.StatusCode != http.StatusOK {
+		if resp.StatusCode == http.StatusNotFound {
+			return nil, ErrNotFound
+		}
+		return nil, errors.New(resp.Status)
 	}
-	var v RestV1EventsOnThisDayResponse
+	var v RestV1OnThisDayResponse
 	if err := httpclient.DeserializeJSON(resp, &v); err != nil {
-		return RestV1EventsOnThisDayResponse{}, err
+		return nil, err
 	}
-	return v, nil
+	return v.Entries(feedType), nil
 }
 
-type RestV1EventsOnThisDayResponse struct {
-	Events []struct {
-		Text  string `json:"text"`
-		Year  int    `json:"year"`
-		Pages []struct {
-			Title  string `json:"title"`
-			Titles struct {
-				Canonical  string `json:"canonical"`
-				Normalized string `json:"normalized"`
-				Display    string `json:"display"`
-			} `json:"titles"`
-			Pageid      int    `json:"pageid"`
-			Extract     string `json:"extract"`
-			ExtractHtml string `json:"extract_html"`
-			Thumbnail   struct {
-				Source string `json:"source"`
-				Width  int    `json:"width"`
-				Height int    `json:"height"`
-			} `json:"thumbnail"`
-			Originalimage struct {
-				Source string `json:"source"`
-				Width  int    `json:"width"`
-				Height int    `json:"height"`
-			} `json:"originalimage"`
-			Lang        string    `json:"lang"`
-			Dir         string    `json:"dir"`
-			Timestamp   time.Time `json:"timestamp"`
-			Description string    `json:"description"`
-			ContentUrls struct {
-				Desktop struct {
-					Page string `json:"page"`
-				}
-			} `json:"content_urls"`
-		} `json:"pages"`
-	} `json:"events"`
+// RestV1OnThisDayResponse holds the entries of the requested feed type only.
+type RestV1OnThisDayResponse struct {
+	Events   []RestV1OnThisDayEntry `json:"events"`
+	Births   []RestV1OnThisDayEntry `json:"births"`
+	Deaths   []RestV1OnThisDayEntry `json:"deaths"`
+	Holidays []RestV1OnThisDayEntry `json:"holidays"`
+	Selected []RestV1OnThisDayEntry `json:"selected"`
+}
+
+// Entries returns the entries of a feed type.
+func (r RestV1OnThisDayResponse) Entries(feedType string) []RestV1OnThisDayEntry {
+	switch feedType {
+	case OnThisDayBirths:
+		return r.Births
+	case OnThisDayDeaths:
+		return r.Deaths
+	case OnThisDayHolidays:
+		return r.Holidays
+	case OnThisDaySelected:
+		return r.Selected
+	default:
+		return r.Events
+	}
+}
+
+// RestV1OnThisDayEntry is an entry of an on this day feed. Holidays have no year.
+type RestV1OnThisDayEntry struct {
+	Text  string `json:"text"`
+	Year  int    `json:"year"`
+	Pages []struct {
+		Title  string `json:"title"`
+		Titles struct {
+			Canonical  string `json:"canonical"`
+			Normalized string `json:"normalized"`
+			Display    string `json:"display"`
+		} `json:"titles"`
+		Pageid      int    `json:"pageid"`
+		Extract     string `json:"extract"`
+		ExtractHtml string `json:"extract_html"`
+		Thumbnail   struct {
+			Source string `json:"source"`
+			Width  int    `json:"width"`
+			Height int    `json:"height"`
+		} `json:"thumbnail"`
+		Originalimage struct {
+			Source string `json:"source"`
+			Width  int    `json:"width"`
+			Height int    `json:"height"`
+		} `json:"originalimage"`
+		Lang        string    `json:"lang"`
+		Dir         string    `json:"dir"`
+		Timestamp   time.Time `json:"timestamp"`
+		Description string    `json:"description"`
+		ContentUrls struct {
+			Desktop struct {
+				Page string `json:"page"`
+			}
+		} `json:"content_urls"`
+	} `json:"pages"`
 }
 
-const wikipediaDumpURL = "https://dumps.wikimedia.org/enwiki/latest/enwiki-latest-all-titles-in-ns0.gz"
+// wikipediaDumpURL is formatted with the language code of the Wikipedia edition, twice.
+const wikipediaDumpURL = "https://dumps.wikimedia.org/%[1]swiki/latest/%[1]swiki-latest-all-titles-in-ns0.gz"
 
 func noopCleanupFunc() error {
 	return nil
 }
 
-func DownloadArticleDump(ctx context.Context) (*bufio.Scanner, func() error, error) {
+// DownloadArticleDump downloads the article titles dump of the Wikipedia edition in the given language.
+func DownloadArticleDump(ctx context.Context, lang string) (*bufio.Scanner, func() error, error) {
 	httpClient := httpclient.New().WithTimeout(5 * time.Minute)
-	resp, err := httpClient.Get(ctx, wikipediaDumpURL)
+	resp, err := httpClient.Get(ctx, fmt.Sprintf(wikipediaDumpURL, strings.ReplaceAll(lang, "-", "_")))
 	if err != nil {
 		return nil, noopCleanupFunc, err
 	}
@@ -249,3 +319,194 @@ func DownloadArticleDump(ctx context.Context) (*bufio.Scanner, func() error, err
 		return os.Remove(f.Name())
 	}, nil
 }
+
+var disambiguationCandidatesBaseParameters = map[string]string{
+	"format":       "json",
+	"action":       "query",
+	"generator":    "links",
+	"gplnamespace": "0",
+	"gpllimit":     "max",
+	"prop":         "pageprops",
+	"ppprop":       "disambiguation",
+	"redirects":    "1",
+}
+
+// DisambiguationCandidates returns the articles linked from a disambiguation page,
+// excluding missing pages and other disambiguation pages.
+func (c Client) DisambiguationCandidates(ctx context.Context, title string) ([]string, error) {
+	resp, err := c.httpClient.Get(ctx, c.actionAPIURL(), httpclient.WithQueryParameters(map[string]string{
+		"titles": title,
+	}), httpclient.WithQueryParameters(disambiguationCandidatesBaseParameters))
+	if err != nil {
+		return nil, err
+	}
+	var linksResponse PageLinksResponse
+	if err := httpclient.DeserializeJSON(resp, &linksResponse); err != nil {
+		return nil, err
+	}
+	var titles []string
+	for _, page := range linksResponse.Query.Pages {
+		if page.Missing != nil {
+			continue
+		}
+		if _, ok := page.Pageprops["disambiguation"]; ok {
+			continue
+		}
+		titles = append(titles, page.Title)
+	}
+	slices.Sort(titles)
+	return titles, nil
+}
+
+type PageLinksResponse struct {
+	Query struct {
+		Pages map[string]struct {
+			Pageid    int               `json:"pageid"`
+			Ns        int               `json:"ns"`
+			Title     string            `json:"title"`
+			Missing   *string           `json:"missing"`
+			Pageprops map[string]string `json:"pageprops"`
+		} `json:"pages"`
+	} `json:"query"`
+}
+
+var categoryMembersBaseParameters = map[string]string{
+	"format":      "json",
+	"action":      "query",
+	"list":        "categorymembers",
+	"cmnamespace": "0",
+	"cmtype":      "page",
+	"cmlimit":     "50",
+}
+
+// CategoryMembers returns up to 50 articles belonging to a category.
+func (c Client) CategoryMembers(ctx context.Context, category string) ([]string, error) {
+	resp, err := c.httpClient.Get(ctx, c.actionAPIURL(), httpclient.WithQueryParameters(map[string]string{
+		"cmtitle": "Category:" + category,
+	}), httpclient.WithQueryParameters(categoryMembersBaseParameters))
+	if err != nil {
+		return nil, err
+	}
+	var membersResponse CategoryMembersResponse
+	if err := httpclient.DeserializeJSON(resp, &membersResponse); err != nil {
+		return nil, err
+	}
+	titles := make([]string, 0, len(membersResponse.Query.Categorymembers))
+	for _, member := range membersResponse.Query.Categorymembers {
+		titles = append(titles, member.Title)
+	}
+	return titles, nil
+}
+
+// maxCategoryMemberPages bounds the continuation requests made for categories with many members.
+const maxCategoryMemberPages = 20
+
+// AllCategoryMembers returns the articles belonging to a category, following continuations
+// up to maxCategoryMemberPages responses.
+func (c Client) AllCategoryMembers(ctx context.Context, category string) ([]string, error) {
+	var titles []string
+	continuation := map[string]string{"cmlimit": "max"}
+	for page := 0; page < maxCategoryMemberPages; page++ {
+		resp, err := c.httpClient.Get(ctx, c.actionAPIURL(), httpclient.WithQueryParameters(map[string]string{
+			"cmtitle": "Category:" + category,
+		}), httpclient.WithQueryParameters(categoryMembersBaseParameters), httpclient.WithQueryParameters(continuation))
+		if err != nil {
+			return nil, err
+		}
+		var membersResponse CategoryMembersResponse
+		if err := httpclient.DeserializeJSON(resp, &membersResponse); err != nil {
+			return nil, err
+		}
+		for _, member := range membersResponse.Query.Categorymembers {
+			titles = append(titles, member.Title)
+		}
+		if membersResponse.Continue.Cmcontinue == "" {
+			break
+		}
+		continuation = map[string]string{
+			"cmlimit":    "max",
+			"cmcontinue": membersResponse.Continue.Cmcontinue,
+			"continue":   membersResponse.Continue.Continue,
+		}
+	}
+	return titles, nil
+}
+
+type CategoryMembersResponse struct {
+	Continue struct {
+		Cmcontinue string `json:"cmcontinue"`
+		Continue   string `json:"continue"`
+	} `json:"continue"`
+	Query struct {
+		Categorymembers []struct {
+			Pageid int    `json:"pageid"`
+			Ns     int    `json:"ns"`
+			Title  string `json:"title"`
+		} `json:"categorymembers"`
+	} `json:"query"`
+}
+
+var linksBaseParameters = map[string]string{
+	"format":      "json",
+	"action":      "query",
+	"prop":        "links",
+	"plnamespace": "0",
+	"pllimit":     "max",
+	"redirects":   "1",
+}
+
+// maxLinkPages bounds the continuation requests made for pages with many links.
+const maxLinkPages = 5
+
+// Links returns the articles linked from a page, following continuations up to maxLinkPages responses.
+func (c Client) Links(ctx context.Context, title string) ([]string, error) {
+	var titles []string
+	continuation := map[string]string{}
+	for page := 0; page < maxLinkPages; page++ {
+		resp, err := c.httpClient.Get(ctx, c.actionAPIURL(), httpclient.WithQueryParameters(map[string]string{
+			"titles": title,
+		}), httpclient.WithQueryParameters(linksBaseParameters), httpclient.WithQueryParameters(continuation))
+		if err != nil {
+			return nil, err
+		}
+		var linksResponse LinksResponse
+		if err := httpclient.DeserializeJSON(resp, &linksResponse); err != nil {
+			return nil, err
+		}
+		for _, p := range linksResponse.Query.Pages {
+			if p.Missing != nil {
+				return nil, ErrNotFound
+			}
+			for _, link := range p.Links {
+				titles = append(titles, link.Title)
+			}
+		}
+		if linksResponse.Continue.Plcontinue == "" {
+			break
+		}
+		continuation = map[string]string{
+			"plcontinue": linksResponse.Continue.Plcontinue,
+			"continue":   linksResponse.Continue.Continue,
+		}
+	}
+	return titles, nil
+}
+
+type LinksResponse struct {
+	Continue struct {
+		Plcontinue string `json:"plcontinue"`
+		Continue   string `json:"continue"`
+	} `json:"continue"`
+	Query struct {
+		Pages map[string]struct {
+			Pageid  int     `json:"pageid"`
+			Ns      int     `json:"ns"`
+			Title   string  `json:"title"`
+			Missing *string `json:"missing"`
+			Links   []struct {
+				Ns    int    `json:"ns"`
+				Title string `json:"title"`
+			} `json:"links"`
+		} `json:"pages"`
+	} `json:"query"`
+}
@@ -0,0 +1,95 @@
+package main
+
+import (
+	"context"
+	"html"
+	"net/url"
+	"regexp"
+	"strings"
+	"time"
+
+	"knowledgeleaf/externalapi/wikipedia"
+)
+
+var htmlTagPattern = regexp.MustCompile(`<[^>]*>`)
+
+// featuredContent returns the featured content of a day in the Wikipedia edition of the language.
+func featuredContent(ctx context.Context, lang string, dt time.Time) (FeaturedResponse, error) {
+	feed, err := wikipedia.NewLanguageClient(lang).Featured(ctx, dt)
+	if err != nil {
+		return FeaturedResponse{}, err
+	}
+	resp := FeaturedResponse{
+		Date:       dt.Format(time.DateOnly),
+		MostRead:   []MostReadArticle{},
+		News:       []FeaturedNewsItem{},
+		AppLinkURL: featuredAppLinkURL(lang, dt),
+	}
+	if feed.Tfa != nil {
+		article := featuredSummary(lang, *feed.Tfa)
+		resp.Article = &article
+	}
+	if feed.Mostread != nil {
+		for _, article := range feed.Mostread.Articles {
+			resp.MostRead = append(resp.MostRead, MostReadArticle{
+				WikiSummary: featuredSummary(lang, article.RestV1SummaryResponse),
+				Views:       article.Views,
+				Rank:        article.Rank,
+			})
+		}
+	}
+	if image := feed.Image; image != nil {
+		resp.Picture = &FeaturedPicture{
+			Title:       image.Title,
+			Description: plainText(image.Description.Text),
+			Artist:      plainText(image.Artist.Text),
+			Credit:      plainText(image.Credit.Text),
+			License:     image.License.Type,
+			LicenseURL:  image.License.URL,
+			Image: Image{
+				URL:    image.Image.Source,
+				Width:  image.Image.Width,
+				Height: image.Image.Height,
+			},
+			Thumbnail: Image{
+				URL:    image.Thumbnail.Source,
+				Width:  image.Thumbnail.Width,
+				Height: image.Thumbnail.Height,
+			},
+			URL: image.FilePage,
+		}
+	}
+	for _, item := range feed.News {
+		news := FeaturedNewsItem{
+			Story:    plainText(item.Story),
+			Articles: make([]WikiSummary, 0, len(item.Links)),
+		}
+		for _, link := range item.Links {
+			news.Articles = append(news.Articles, featuredSummary(lang, link))
+		}
+		resp.News = append(resp.News, news)
+	}
+	return resp, nil
+}
+
+// featuredSummary converts a page of the feed, whose title is in its URL form, to a summary.
+func featuredSummary(lang string, page wikipedia.RestV1SummaryResponse) WikiSummary {
+	title := page.Titles.Canonical
+	if title == "" {
+		title = seenKey(page.Title)
+	}
+	if page.Titles.Normalized != "" {
+		page.Title = page.Titles.Normalized
+	}
+	return newWikiSummary(newArticle(lang, title, page, nil))
+}
+
+// plainText strips the markup of an HTML fragment.
+func plainText(fragment string) string {
+	return strings.TrimSpace(html.UnescapeString(htmlTagPattern.ReplaceAllString(fragment, "")))
+}
+
+// featuredAppLinkURL returns the Knowledge Leaf permalink of the featured content of a day, in the given language.
+func featuredAppLinkURL(lang string, dt time.Time) string {
+	return "/featured/" + dt.Format(time.DateOnly) + "?" + url.Values{"lang": {lang}}.Encode()
+}
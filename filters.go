@@ -0,0 +1,99 @@
+package main
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"knowledgeleaf/app"
+)
+
+// ArticleFilter decides whether an article is suitable to be served as trivia.
+type ArticleFilter interface {
+	// Reject returns the reason the article is rejected, or an empty string if it is accepted.
+	Reject(summary WikiSummary) string
+}
+
+type minExtractLengthFilter int
+
+func (f minExtractLengthFilter) Reject(summary WikiSummary) string {
+	if len([]rune(summary.Summary)) < int(f) {
+		return fmt.Sprintf("extract shorter than %d characters", f)
+	}
+	return ""
+}
+
+type requireImageFilter struct{}
+
+func (f requireImageFilter) Reject(summary WikiSummary) string {
+	if summary.Metadata.Image.URL == "" {
+		return "no image"
+	}
+	return ""
+}
+
+type blockedTitlePatternsFilter []*regexp.Regexp
+
+func (f blockedTitlePatternsFilter) Reject(summary WikiSummary) string {
+	title := titleKey(summary.Title)
+	for _, pattern := range f {
+		if pattern.MatchString(title) {
+			return fmt.Sprintf("title matches %q", pattern.String())
+		}
+	}
+	return ""
+}
+
+// blockedCategoriesFilter matches category names case-insensitively.
+type blockedCategoriesFilter map[string]struct{}
+
+func (f blockedCategoriesFilter) Reject(summary WikiSummary) string {
+	for _, category := range summary.Categories {
+		if _, ok := f[strings.ToLower(category)]; ok {
+			return fmt.Sprintf("blocked category %q", category)
+		}
+	}
+	return ""
+}
+
+// NewArticleFilters builds the filter pipeline from the configuration.
+// Title patterns are matched against titles with spaces instead of underscores.
+// Patterns and categories are separated by semicolons, as both may contain commas.
+func NewArticleFilters(cfg app.Configuration) ([]ArticleFilter, error) {
+	var filters []ArticleFilter
+	if cfg.FilterMinExtractLength > 0 {
+		filters = append(filters, minExtractLengthFilter(cfg.FilterMinExtractLength))
+	}
+	if cfg.FilterRequireImage {
+		filters = append(filters, requireImageFilter{})
+	}
+	if len(cfg.FilterBlockedTitlePatterns) > 0 {
+		var patterns blockedTitlePatternsFilter
+		for _, p := range cfg.FilterBlockedTitlePatterns {
+			pattern, err := regexp.Compile(p)
+			if err != nil {
+				return nil, fmt.Errorf("invalid blocked title pattern %q: %w", p, err)
+			}
+			patterns = append(patterns, pattern)
+		}
+		filters = append(filters, patterns)
+	}
+	if len(cfg.FilterBlockedCategories) > 0 {
+		categories := make(blockedCategoriesFilter, len(cfg.FilterBlockedCategories))
+		for _, category := range cfg.FilterBlockedCategories {
+			categories[strings.ToLower(normalizeCategory(category))] = struct{}{}
+		}
+		filters = append(filters, categories)
+	}
+	return filters, nil
+}
+
+// rejectArticle runs the filter pipeline and returns the first rejection reason.
+func rejectArticle(filters []ArticleFilter, summary WikiSummary) string {
+	for _, f := range filters {
+		if reason := f.Reject(summary); reason != "" {
+			return reason
+		}
+	}
+	return ""
+}
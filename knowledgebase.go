@@ -0,0 +1,78 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/signal"
+	"sync"
+	"syscall"
+
+	"go.uber.org/zap"
+
+	"knowledgeleaf/app"
+	"knowledgeleaf/knowledgebase"
+	"knowledgeleaf/repository"
+)
+
+// ErrReloadUnsupported is returned when the titles are not served from the knowledge base.
+var ErrReloadUnsupported = errors.New("knowledge base reload requires TITLE_STORE embedded")
+
+// KnowledgeBase reloads the title lists of KNOWLEDGEBASE_DIR into the embedded title store.
+type KnowledgeBase struct {
+	triviaBackend *RandomTriviaBackend
+	// mu serializes reloads, as the store stages a single load at a time
+	mu sync.Mutex
+}
+
+func NewKnowledgeBase(triviaBackend *RandomTriviaBackend) *KnowledgeBase {
+	return &KnowledgeBase{triviaBackend: triviaBackend}
+}
+
+// Reload replaces the served titles with the current title lists and returns their count.
+// The served titles are left unchanged when the lists cannot be loaded.
+func (k *KnowledgeBase) Reload(ctx context.Context) (int, error) {
+	application := k.triviaBackend.application
+	store, ok := application.DefaultTitles().(repository.StagedTitleStore)
+	if application.Cfg.TitleStore != app.TitleStoreEmbedded || !ok {
+		return 0, ErrReloadUnsupported
+	}
+	titles, err := knowledgebase.Titles(application.Cfg.KnowledgeBaseDir)
+	if err != nil {
+		return 0, err
+	}
+
+	k.mu.Lock()
+	defer k.mu.Unlock()
+	if err := store.Stage(ctx, titles); err != nil {
+		return 0, err
+	}
+	if err := store.Commit(ctx); err != nil {
+		return 0, err
+	}
+	application.Logger.Info(fmt.Sprintf("reloaded %d titles", len(titles)))
+	if _, err := k.triviaBackend.RefreshTitleCount(ctx); err != nil {
+		return 0, err
+	}
+	return len(titles), nil
+}
+
+// ReloadOnSignal reloads the knowledge base whenever the process receives SIGHUP, until ctx is done.
+func (k *KnowledgeBase) ReloadOnSignal(ctx context.Context) {
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, syscall.SIGHUP)
+	go func() {
+		defer signal.Stop(signals)
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-signals:
+				if _, err := k.Reload(ctx); err != nil {
+					k.triviaBackend.application.Logger.Error("reloading knowledge base failed", zap.Error(err))
+				}
+			}
+		}
+	}()
+}
@@ -0,0 +1,160 @@
+package knowledgebase
+
+import (
+	"bufio"
+	"embed"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"slices"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// https://dumps.wikimedia.org/enwiki/latest/
+//
+//go:embed samples/wikipedia-article-list-samples-*.json
+var samplesFS embed.FS
+
+// ErrNoTitles is returned when a knowledge base directory holds no valid title.
+var ErrNoTitles = errors.New("no titles found")
+
+// maxTitleBytes is the maximum length of a Wikipedia title.
+const maxTitleBytes = 255
+
+// invalidTitleChars cannot be part of a Wikipedia title.
+const invalidTitleChars = "#<>[]{}|"
+
+// Samples returns the sample article titles embedded in the binary.
+func Samples() ([]string, error) {
+	return load(samplesFS, "samples")
+}
+
+// Titles returns the titles of the lists in dir, or the embedded samples when dir is empty.
+// Lists are JSON arrays of strings (.json), one JSON string or {"title": ...} object per line (.jsonl),
+// or one title per line (.txt). Titles are validated, normalized to their URL form and deduplicated.
+func Titles(dir string) ([]string, error) {
+	if dir == "" {
+		return Samples()
+	}
+	titles, err := load(os.DirFS(dir), ".")
+	if err != nil {
+		return nil, fmt.Errorf("knowledge base %s: %w", dir, err)
+	}
+	return titles, nil
+}
+
+func load(fsys fs.FS, dir string) ([]string, error) {
+	entries, err := fs.ReadDir(fsys, dir)
+	if err != nil {
+		return nil, err
+	}
+	var titles []string
+	seen := make(map[string]struct{})
+	// Entries are sorted by name, so that the title order does not depend on the file system
+	for _, entry := range entries {
+		if entry.IsDir() {
+			continue
+		}
+		name := path.Join(dir, entry.Name())
+		parse, ok := parsers[strings.ToLower(filepath.Ext(name))]
+		if !ok {
+			continue
+		}
+		f, err := fsys.Open(name)
+		if err != nil {
+			return nil, err
+		}
+		elements, err := parse(f)
+		_ = f.Close()
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", name, err)
+		}
+		for _, title := range elements {
+			title, ok := normalizeTitle(title)
+			if !ok {
+				continue
+			}
+			if _, ok := seen[title]; ok {
+				continue
+			}
+			seen[title] = struct{}{}
+			titles = append(titles, title)
+		}
+	}
+	if len(titles) == 0 {
+		return nil, ErrNoTitles
+	}
+	return titles, nil
+}
+
+var parsers = map[string]func(io.Reader) ([]string, error){
+	".json":  parseJSON,
+	".jsonl": parseJSONLines,
+	".txt":   parseLines,
+}
+
+func parseJSON(r io.Reader) ([]string, error) {
+	var titles []string
+	if err := json.NewDecoder(r).Decode(&titles); err != nil {
+		return nil, err
+	}
+	return titles, nil
+}
+
+func parseJSONLines(r io.Reader) ([]string, error) {
+	lines, err := parseLines(r)
+	if err != nil {
+		return nil, err
+	}
+	titles := make([]string, 0, len(lines))
+	for i, line := range lines {
+		var title string
+		if strings.HasPrefix(line, "{") {
+			var item struct {
+				Title string `json:"title"`
+			}
+			err = json.Unmarshal([]byte(line), &item)
+			title = item.Title
+		} else {
+			err = json.Unmarshal([]byte(line), &title)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %w", i+1, err)
+		}
+		titles = append(titles, title)
+	}
+	return titles, nil
+}
+
+// parseLines returns the non-blank lines.
+func parseLines(r io.Reader) ([]string, error) {
+	var lines []string
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		if line := strings.TrimSpace(scanner.Text()); line != "" {
+			lines = append(lines, line)
+		}
+	}
+	return lines, scanner.Err()
+}
+
+// normalizeTitle converts a title to the underscore separated form of the dumps,
+// reporting whether it is a valid Wikipedia title.
+func normalizeTitle(s string) (string, bool) {
+	s = strings.TrimPrefix(s, "\ufeff")
+	s = strings.Join(strings.Fields(s), "_")
+	if s == "" || len(s) > maxTitleBytes || !utf8.ValidString(s) {
+		return "", false
+	}
+	if strings.ContainsAny(s, invalidTitleChars) || slices.ContainsFunc([]rune(s), unicode.IsControl) {
+		return "", false
+	}
+	return s, true
+}
@@ -1,11 +1,16 @@
 package main
 
 import (
+	"context"
+	"crypto/subtle"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"net/http"
 	"net/url"
 	"os"
+	"slices"
+	"strconv"
 	"strings"
 	"time"
 
@@ -16,6 +21,7 @@ import (
 
 	"knowledgeleaf/app"
 	"knowledgeleaf/externalapi/wikipedia"
+	"knowledgeleaf/repository"
 )
 
 type requestLogger struct {
@@ -50,7 +56,8 @@ func main() {
 	r.Use(cors.Handler(cors.Options{
 		AllowedOrigins:   application.Cfg.AllowedOrigins,
 		AllowedMethods:   []string{"GET", "POST", "PUT", "DELETE", "OPTIONS"},
-		AllowedHeaders:   []string{"Accept", "Authorization", "Content-Type", "X-CSRF-Token"},
+		AllowedHeaders:   []string{"Accept", "Authorization", "Content-Type", "X-CSRF-Token", sessionHeader, timezoneHeader},
+		ExposedHeaders:   []string{sessionHeader},
 		AllowCredentials: false,
 		MaxAge:           300, // Maximum value not ignored by any of major browsers
 	}))
@@ -73,7 +80,37 @@ func main() {
 		})
 	})
 
-	triviaBackend := NewRandomTriviaBackend(application)
+	triviaBackend, err := NewRandomTriviaBackend(application)
+	if err != nil {
+		panic(err)
+	}
+
+	quiz, err := NewQuiz(triviaBackend, application.Cfg.QuizSecret, application.Cfg.QuizTokenTTL)
+	if err != nil {
+		panic(err)
+	}
+
+	var triviaPool *TriviaPool
+	if application.Cfg.PrefetchPoolSize > 0 {
+		prefetchCtx, cancelPrefetch := context.WithCancel(context.Background())
+		defer cancelPrefetch()
+		triviaPool = NewTriviaPool(triviaBackend, application.Cfg.PrefetchPoolSize, application.Cfg.PrefetchWorkers)
+		triviaPool.Start(prefetchCtx)
+	}
+
+	watchCtx, cancelWatch := context.WithCancel(context.Background())
+	defer cancelWatch()
+	triviaBackend.WatchTitleCount(watchCtx)
+	triviaBackend.PurgeExpiredSessions(watchCtx)
+
+	knowledgeBase := NewKnowledgeBase(triviaBackend)
+	onThisDay := newOnThisDayFeeds(application)
+	daily := newDailyArticles(application)
+	if application.Cfg.TitleStore == app.TitleStoreEmbedded {
+		reloadCtx, cancelReload := context.WithCancel(context.Background())
+		defer cancelReload()
+		knowledgeBase.ReloadOnSignal(reloadCtx)
+	}
 
 	// Routes
 	r.Get("/trivia/random", func(w http.ResponseWriter, r *http.Request) {
@@ -86,45 +123,358 @@ func main() {
 		}
 		logger = logger.With(loggerFields...)
 
-		// Search for a Wikipedia article
-		summaries, err := randomizeArticle(ctx, triviaBackend)
+		lang, ok := requestLanguage(w, r, application.Cfg)
+		if !ok {
+			return
+		}
+		query := TitleQuery{Lang: lang}
+		for _, category := range r.URL.Query()["category"] {
+			if category = normalizeCategory(category); category != "" {
+				query.Categories = append(query.Categories, category)
+			}
+		}
+		if len(query.Categories) > maxCategoryFilters {
+			http.Error(w, fmt.Sprintf("at most %d categories are allowed", maxCategoryFilters), http.StatusBadRequest)
+			return
+		}
+
+		query.Seed = r.URL.Query().Get("seed")
+		if len(query.Seed) > maxSeedLength {
+			http.Error(w, fmt.Sprintf("seed must be at most %d characters long", maxSeedLength), http.StatusBadRequest)
+			return
+		}
+		if v := r.URL.Query().Get("index"); v != "" {
+			n, err := strconv.Atoi(v)
+			if err != nil || n < 0 || query.Seed == "" {
+				http.Error(w, "index must be a non-negative integer used along with seed", http.StatusBadRequest)
+				return
+			}
+			query.Index = n
+		}
+
+		count := 1
+		if v := r.URL.Query().Get("count"); v != "" {
+			n, err := strconv.Atoi(v)
+			if err != nil || n < 1 || n > application.Cfg.TriviaMaxCount {
+				http.Error(w,
+					fmt.Sprintf("count must be between 1 and %d", application.Cfg.TriviaMaxCount),
+					http.StatusBadRequest)
+				return
+			}
+			count = n
+		}
+
+		if query.Seed == "" {
+			// Seeded requests are reproducible, hence not tied to the client session
+			query.Session = clientSession(w, r, application.Cfg.SessionSeenTTL)
+		}
+
+		var prefetched []WikiSummary
+		// The prefetch pool only holds articles of the default language
+		if query.isUnrestricted() && lang == application.Cfg.DefaultLanguage() {
+			for len(prefetched) < count {
+				summary, ok := triviaPool.Pop()
+				if !ok {
+					break
+				}
+				claimed, err := triviaBackend.claimForSession(ctx, query.Session, lang, summary.Title)
+				if err != nil {
+					logger.Error(err.Error(), zap.Error(err))
+					http.Error(w, "request failed", http.StatusInternalServerError)
+					return
+				}
+				if claimed {
+					prefetched = append(prefetched, summary)
+				}
+			}
+		}
+		if len(prefetched) == count {
+			writeRandomTriviaResponse(w, logger, RandomTriviaResponse{Results: prefetched})
+			recordViews(ctx, triviaBackend, logger, prefetched)
+			return
+		}
+
+		// Search for Wikipedia articles
+		summaries, failures, err := randomizeArticles(ctx, triviaBackend, query, count, prefetched)
+		if err != nil {
+			for _, summary := range prefetched {
+				triviaBackend.forgetForSession(ctx, query.Session, lang, summary.Title)
+			}
+			writeTriviaError(w, logger, err)
+			return
+		}
+		writeRandomTriviaResponse(w, logger, RandomTriviaResponse{Results: summaries, Errors: failures})
+		recordViews(ctx, triviaBackend, logger, summaries)
+	})
+	r.Get("/trivia/next", func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+		logger := app.LoggerFromContext(ctx)
+		loggerFields := []zap.Field{
+			zap.String("requestID", middleware.GetReqID(ctx)),
+			zap.String("httpMethod", http.MethodGet),
+			zap.String("operation", "trivia/next"),
+		}
+		logger = logger.With(loggerFields...)
+
+		lang, ok := requestLanguage(w, r, application.Cfg)
+		if !ok {
+			return
+		}
+		from := titleKey(strings.TrimSpace(r.URL.Query().Get("from")))
+		if from == "" {
+			http.Error(w, "from is required", http.StatusBadRequest)
+			return
+		}
+		var path []string
+		for _, title := range r.URL.Query()["path"] {
+			if title = titleKey(strings.TrimSpace(title)); title != "" {
+				path = append(path, title)
+			}
+		}
+		if len(path) >= maxBreadcrumbLength {
+			http.Error(w, fmt.Sprintf("path must have fewer than %d titles", maxBreadcrumbLength), http.StatusBadRequest)
+			return
+		}
+
+		summary, err := nextArticle(ctx, triviaBackend, lang, from, path)
+		switch {
+		case errors.Is(err, wikipedia.ErrNotFound):
+			http.Error(w, "page not found", http.StatusNotFound)
+			return
+		case errors.Is(err, ErrNoLinkedArticle):
+			http.Error(w, err.Error(), http.StatusNotFound)
+			return
+		case err != nil:
+			writeTriviaError(w, logger, err)
+			return
+		}
+		path = append(path, from, titleKey(summary.Title))
+		writeJSONResponse(w, logger, NextTriviaResponse{Result: summary, Path: path})
+		recordViews(ctx, triviaBackend, logger, []WikiSummary{summary})
+	})
+	r.Get("/trivia/daily", func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+		logger := app.LoggerFromContext(ctx)
+		loggerFields := []zap.Field{
+			zap.String("requestID", middleware.GetReqID(ctx)),
+			zap.String("httpMethod", http.MethodGet),
+			zap.String("operation", "trivia/daily"),
+		}
+		logger = logger.With(loggerFields...)
+
+		now := time.Now().UTC()
+		summaries, err := daily.Summaries(ctx, triviaBackend, now)
+		if err != nil {
+			writeTriviaError(w, logger, err)
+			return
+		}
+		nextDay := time.Date(now.Year(), now.Month(), now.Day()+1, 0, 0, 0, 0, time.UTC)
+		w.Header().Set("Cache-Control", fmt.Sprintf("public, max-age=%d", int(nextDay.Sub(now).Seconds())))
+		writeRandomTriviaResponse(w, logger, RandomTriviaResponse{Results: summaries})
+		recordViews(ctx, triviaBackend, logger, summaries)
+	})
+	r.Get("/quiz/question", func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+		logger := app.LoggerFromContext(ctx)
+		loggerFields := []zap.Field{
+			zap.String("requestID", middleware.GetReqID(ctx)),
+			zap.String("httpMethod", http.MethodGet),
+			zap.String("operation", "quiz/question"),
+		}
+		logger = logger.With(loggerFields...)
+
+		summary, ok := triviaPool.Pop()
+		if !ok {
+			summaries, err := randomizeArticle(ctx, triviaBackend, TitleQuery{})
+			if err != nil {
+				writeTriviaError(w, logger, err)
+				return
+			}
+			summary = summaries[0]
+		}
+		question, err := quiz.NewQuestion(ctx, summary)
 		if err != nil {
 			logger.Error(err.Error(), zap.Error(err))
 			http.Error(w, "request failed", http.StatusInternalServerError)
 			return
 		}
-		b, err := json.Marshal(RandomTriviaResponse{Results: summaries})
+		writeJSONResponse(w, logger, question)
+	})
+	r.Post("/quiz/answer", func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+		logger := app.LoggerFromContext(ctx)
+		loggerFields := []zap.Field{
+			zap.String("requestID", middleware.GetReqID(ctx)),
+			zap.String("httpMethod", http.MethodPost),
+			zap.String("operation", "quiz/answer"),
+		}
+		logger = logger.With(loggerFields...)
+
+		var req QuizAnswerRequest
+		if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxQuizAnswerBytes)).Decode(&req); err != nil {
+			http.Error(w, "invalid request body", http.StatusBadRequest)
+			return
+		}
+		resp, err := quiz.CheckAnswer(req.Token, req.Answer)
 		if err != nil {
-			logger.Error(err.Error(),
-				zap.Error(err),
-				zap.String("operationDetail", "jsonMarshal"))
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		writeJSONResponse(w, logger, resp)
+	})
+	r.Get("/trivia/stats", func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+		logger := app.LoggerFromContext(ctx)
+		loggerFields := []zap.Field{
+			zap.String("requestID", middleware.GetReqID(ctx)),
+			zap.String("httpMethod", http.MethodGet),
+			zap.String("operation", "trivia/stats"),
+		}
+		logger = logger.With(loggerFields...)
+
+		limit := defaultStatsArticleLimit
+		if v := r.URL.Query().Get("limit"); v != "" {
+			n, err := strconv.Atoi(v)
+			if err != nil || n < 1 || n > maxStatsArticleLimit {
+				http.Error(w,
+					fmt.Sprintf("limit must be between 1 and %d", maxStatsArticleLimit),
+					http.StatusBadRequest)
+				return
+			}
+			limit = n
+		}
+		resp, err := triviaStats(ctx, triviaBackend, limit)
+		if err != nil {
+			logger.Error(err.Error(), zap.Error(err))
 			http.Error(w, "request failed", http.StatusInternalServerError)
 			return
 		}
-		if _, err := w.Write(b); err != nil {
-			logger.Error(err.Error(),
-				zap.Error(err),
-				zap.String("operationDetail", "responseWrite"))
+		writeJSONResponse(w, logger, resp)
+	})
+	r.Get("/titles/search", func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+		logger := app.LoggerFromContext(ctx)
+		loggerFields := []zap.Field{
+			zap.String("requestID", middleware.GetReqID(ctx)),
+			zap.String("httpMethod", http.MethodGet),
+			zap.String("operation", "titles/search"),
+		}
+		logger = logger.With(loggerFields...)
+
+		lang, ok := requestLanguage(w, r, application.Cfg)
+		if !ok {
+			return
+		}
+		q := strings.TrimSpace(r.URL.Query().Get("q"))
+		if q == "" || len(q) > maxSearchQueryLen {
+			http.Error(w, fmt.Sprintf("q must be between 1 and %d characters long", maxSearchQueryLen), http.StatusBadRequest)
+			return
+		}
+		limit := defaultSearchLimit
+		if v := r.URL.Query().Get("limit"); v != "" {
+			n, err := strconv.Atoi(v)
+			if err != nil || n < 1 || n > maxSearchLimit {
+				http.Error(w, fmt.Sprintf("limit must be between 1 and %d", maxSearchLimit), http.StatusBadRequest)
+				return
+			}
+			limit = n
+		}
+		var offset int
+		if v := r.URL.Query().Get("offset"); v != "" {
+			n, err := strconv.Atoi(v)
+			if err != nil || n < 0 || n > maxSearchOffset {
+				http.Error(w, fmt.Sprintf("offset must be between 0 and %d", maxSearchOffset), http.StatusBadRequest)
+				return
+			}
+			offset = n
+		}
+
+		resp, err := searchTitles(ctx, triviaBackend, lang, q, limit, offset)
+		if errors.Is(err, ErrSearchUnsupported) {
+			http.Error(w, err.Error(), http.StatusNotImplemented)
+			return
+		}
+		if errors.Is(err, ErrLanguageUnsupported) {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		if err != nil {
+			logger.Error(err.Error(), zap.Error(err))
 			http.Error(w, "request failed", http.StatusInternalServerError)
 			return
 		}
+		writeJSONResponse(w, logger, resp)
 	})
-	r.Get("/trivia/stats", func(w http.ResponseWriter, r *http.Request) {
-		// TODO: return total database size count & views
+	r.Get("/articles/{title}", func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+		logger := app.LoggerFromContext(ctx)
+		loggerFields := []zap.Field{
+			zap.String("requestID", middleware.GetReqID(ctx)),
+			zap.String("httpMethod", http.MethodGet),
+			zap.String("operation", "articles"),
+		}
+		logger = logger.With(loggerFields...)
+
+		lang, ok := requestLanguage(w, r, application.Cfg)
+		if !ok {
+			return
+		}
+		requested, err := url.PathUnescape(chi.URLParam(r, "title"))
+		if err != nil {
+			http.Error(w, "invalid title", http.StatusBadRequest)
+			return
+		}
+		title, ok := canonicalTitle(requested)
+		if !ok {
+			http.Error(w, "invalid title", http.StatusBadRequest)
+			return
+		}
+		if title != requested {
+			http.Redirect(w, r, articleAppLinkURL(lang, title), http.StatusMovedPermanently)
+			return
+		}
+
+		summary, err := fetchArticle(ctx, triviaBackend, lang, title)
+		if errors.Is(err, wikipedia.ErrNotFound) {
+			http.Error(w, "article not found", http.StatusNotFound)
+			return
+		}
+		if err != nil {
+			logger.Error(err.Error(), zap.Error(err), zap.String("title", title))
+			http.Error(w, "request failed", http.StatusInternalServerError)
+			return
+		}
+		// Redirect pages resolve to their target article, which has its own permalink
+		if isRedirect(title, summary.Title) {
+			http.Redirect(w, r, summary.AppLinkURL, http.StatusMovedPermanently)
+			return
+		}
+		writeJSONResponse(w, logger, summary)
+		recordViews(ctx, triviaBackend, logger, []WikiSummary{summary})
 	})
-	r.Get("/on-this-day/events/{date}/{title}", func(w http.ResponseWriter, r *http.Request) {
+	r.Get("/on-this-day/{type}/{date}/{title}", func(w http.ResponseWriter, r *http.Request) {
 		ctx := r.Context()
 		logger := app.LoggerFromContext(ctx)
 		cc := chi.RouteContext(ctx)
 		params := struct {
-			date  string
-			title string
+			feedType string
+			date     string
+			title    string
 		}{
-			date:  chi.URLParam(r, "date"),
-			title: chi.URLParam(r, "title"),
+			feedType: chi.URLParam(r, "type"),
+			date:     chi.URLParam(r, "date"),
+			title:    chi.URLParam(r, "title"),
+		}
+		if !slices.Contains(wikipedia.OnThisDayTypes, params.feedType) {
+			http.NotFound(w, r)
+			return
 		}
 
 		params.title, _ = url.PathUnescape(params.title)
+		lang, ok := requestLanguage(w, r, application.Cfg)
+		if !ok {
+			return
+		}
 
 		hasNegativeYear := strings.HasPrefix(params.date, "-")
 		dateParam := params.date
@@ -147,160 +497,157 @@ func main() {
 		}
 		logger = logger.With(loggerFields...)
 
-		client := wikipedia.NewClient()
-		events, err := client.OnThisDay(ctx, dt)
+		entries, err := onThisDay.Entries(ctx, lang, params.feedType, dt)
+		if errors.Is(err, wikipedia.ErrNotFound) {
+			http.Error(w, "no entries found for this date", http.StatusNotFound)
+			return
+		}
 		if err != nil {
 			logger.Error(err.Error(), zap.Error(err))
 			http.Error(w, "request failed", http.StatusInternalServerError)
 			return
 		}
 		var resp EventsOnThisDayResponse
-		for _, ev := range events.Events {
-			if ev.Year != dt.Year() {
+		for _, entry := range entries {
+			// Holidays have no year, they match the date of any year
+			if len(entry.Pages) == 0 || (params.feedType != wikipedia.OnThisDayHolidays && entry.Year != dt.Year()) {
 				continue
 			}
-			mainPage := ev.Pages[0]
-			parsedContentURL, err := url.Parse(mainPage.ContentUrls.Desktop.Page)
+			urlTitle, err := pageURLTitle(entry.Pages[0].ContentUrls.Desktop.Page)
 			if err != nil {
 				logger.Error(err.Error(), zap.Error(err))
 				http.Error(w, "request failed", http.StatusInternalServerError)
 				return
 			}
-			parsedURL := strings.Split(parsedContentURL.Path, "/")
-			urlTitle := parsedURL[len(parsedURL)-1]
 			if urlTitle != params.title {
 				continue
 			}
-			var references []OnThisDayEventReference
-			if len(ev.Pages) > 1 {
-				for _, p := range ev.Pages[1:] {
-					references = append(references, OnThisDayEventReference{
-						Title: p.Title,
-						URL:   p.ContentUrls.Desktop.Page,
-					})
-				}
-			}
-			appLink, err := appLinkURL(dt, mainPage.ContentUrls.Desktop.Page)
+			event, err := newOnThisDayEvent(lang, params.feedType, entry, dt)
 			if err != nil {
 				logger.Error(err.Error(), zap.Error(err))
 				http.Error(w, "request failed", http.StatusInternalServerError)
 				return
 			}
-			resp.Titles = append(resp.Titles, OnThisDayEvent{
-				Title:      ev.Text,
-				ShortTitle: mainPage.Titles.Normalized,
-				Image: Image{
-					URL:    mainPage.Thumbnail.Source,
-					Width:  mainPage.Thumbnail.Width,
-					Height: mainPage.Thumbnail.Height,
-				},
-				Description: mainPage.Description,
-				Extract:     mainPage.Extract,
-				URL:         mainPage.ContentUrls.Desktop.Page,
-				References:  references,
-				Year:        ev.Year,
-				AppLinkURL:  appLink,
-			})
-		}
-		b, err := json.Marshal(resp)
-		if err != nil {
-			logger.Error(err.Error(),
-				zap.Error(err),
-				zap.String("operationDetail", "jsonMarshal"))
-			http.Error(w, "request failed", http.StatusInternalServerError)
-			return
-		}
-		if _, err := w.Write(b); err != nil {
-			logger.Error(err.Error(),
-				zap.Error(err),
-				zap.String("operationDetail", "responseWrite"))
-			http.Error(w, "request failed", http.StatusInternalServerError)
-			return
+			resp.Titles = append(resp.Titles, event)
 		}
+		writeJSONResponse(w, logger, resp)
 	})
-	r.Get("/on-this-day/events", func(w http.ResponseWriter, r *http.Request) {
+	r.Get("/on-this-day/{type}", func(w http.ResponseWriter, r *http.Request) {
 		ctx := r.Context()
 		logger := app.LoggerFromContext(ctx)
+		feedType := chi.URLParam(r, "type")
+		if !slices.Contains(wikipedia.OnThisDayTypes, feedType) {
+			http.NotFound(w, r)
+			return
+		}
 		loggerFields := []zap.Field{
 			zap.String("requestID", middleware.GetReqID(ctx)),
 			zap.String("httpMethod", http.MethodGet),
-			zap.String("operation", "on-this-day/events"),
+			zap.String("operation", "on-this-day/"+feedType),
 		}
 		logger = logger.With(loggerFields...)
 
-		client := wikipedia.NewClient()
-		now := time.Now().UTC()
-		events, err := client.OnThisDay(ctx, now)
+		lang, ok := requestLanguage(w, r, application.Cfg)
+		if !ok {
+			return
+		}
+
+		day, err := onThisDayDay(r, time.Now())
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		entries, err := onThisDay.Entries(ctx, lang, feedType, day)
+		if errors.Is(err, wikipedia.ErrNotFound) {
+			http.Error(w, "no entries found for this date", http.StatusNotFound)
+			return
+		}
 		if err != nil {
 			logger.Error(err.Error(), zap.Error(err))
 			http.Error(w, "request failed", http.StatusInternalServerError)
 			return
 		}
 		var resp EventsOnThisDayResponse
-		for _, ev := range events.Events {
-			mainPage := ev.Pages[0]
-			var references []OnThisDayEventReference
-			if len(ev.Pages) > 1 {
-				for _, p := range ev.Pages[1:] {
-					references = append(references, OnThisDayEventReference{
-						Title: p.Title,
-						URL:   p.ContentUrls.Desktop.Page,
-					})
-				}
-			}
-			hasNegativeYear := ev.Year < 0
-			displayYear := ev.Year
-			if hasNegativeYear {
-				ev.Year *= -1
+		for _, entry := range entries {
+			if len(entry.Pages) == 0 {
+				continue
 			}
-			eventDate, err := time.Parse(time.DateOnly, fmt.Sprintf("%04d-%s", ev.Year, now.Format("01-02")))
+			eventDate, err := onThisDayDate(feedType, entry.Year, day)
 			if err != nil {
 				logger.Error(err.Error(), zap.Error(err))
 				http.Error(w, "request failed", http.StatusInternalServerError)
 				return
 			}
-			if hasNegativeYear {
-				eventDate = eventDate.AddDate(-2*ev.Year, 0, 0)
-			}
-			appLink, err := appLinkURL(eventDate, mainPage.ContentUrls.Desktop.Page)
+			event, err := newOnThisDayEvent(lang, feedType, entry, eventDate)
 			if err != nil {
 				logger.Error(err.Error(), zap.Error(err))
 				http.Error(w, "request failed", http.StatusInternalServerError)
 				return
 			}
-			resp.Titles = append(resp.Titles, OnThisDayEvent{
-				Title:      ev.Text,
-				ShortTitle: mainPage.Titles.Normalized,
-				Image: Image{
-					URL:    mainPage.Thumbnail.Source,
-					Width:  mainPage.Thumbnail.Width,
-					Height: mainPage.Thumbnail.Height,
-				},
-				Description: mainPage.Description,
-				Extract:     mainPage.Extract,
-				URL:         mainPage.ContentUrls.Desktop.Page,
-				References:  references,
-				Year:        displayYear,
-				AppLinkURL:  appLink,
-			})
+			resp.Titles = append(resp.Titles, event)
+		}
+		writeJSONResponse(w, logger, resp)
+	})
+	r.Get("/featured/{date}", func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+		logger := app.LoggerFromContext(ctx)
+		loggerFields := []zap.Field{
+			zap.String("requestID", middleware.GetReqID(ctx)),
+			zap.String("httpMethod", http.MethodGet),
+			zap.String("operation", "featured"),
+		}
+		logger = logger.With(loggerFields...)
+
+		lang, ok := requestLanguage(w, r, application.Cfg)
+		if !ok {
+			return
 		}
-		b, err := json.Marshal(resp)
+		dt, err := time.Parse(time.DateOnly, chi.URLParam(r, "date"))
 		if err != nil {
-			logger.Error(err.Error(),
-				zap.Error(err),
-				zap.String("operationDetail", "jsonMarshal"))
-			http.Error(w, "request failed", http.StatusInternalServerError)
+			http.Error(w, "date must be formatted as YYYY-MM-DD", http.StatusBadRequest)
+			return
+		}
+
+		resp, err := featuredContent(ctx, lang, dt)
+		if errors.Is(err, wikipedia.ErrNotFound) {
+			http.Error(w, "no featured content for this date", http.StatusNotFound)
 			return
 		}
-		if _, err := w.Write(b); err != nil {
-			logger.Error(err.Error(),
-				zap.Error(err),
-				zap.String("operationDetail", "responseWrite"))
+		if err != nil {
+			logger.Error(err.Error(), zap.Error(err))
 			http.Error(w, "request failed", http.StatusInternalServerError)
 			return
 		}
+		writeJSONResponse(w, logger, resp)
 	})
 
+	if application.Cfg.AdminToken != "" {
+		r.With(adminOnly(application.Cfg.AdminToken)).Post("/admin/knowledgebase/reload",
+			func(w http.ResponseWriter, r *http.Request) {
+				ctx := r.Context()
+				logger := app.LoggerFromContext(ctx)
+				loggerFields := []zap.Field{
+					zap.String("requestID", middleware.GetReqID(ctx)),
+					zap.String("httpMethod", http.MethodPost),
+					zap.String("operation", "admin/knowledgebase/reload"),
+				}
+				logger = logger.With(loggerFields...)
+
+				n, err := knowledgeBase.Reload(ctx)
+				if errors.Is(err, ErrReloadUnsupported) {
+					http.Error(w, err.Error(), http.StatusConflict)
+					return
+				}
+				if err != nil {
+					logger.Error(err.Error(), zap.Error(err))
+					http.Error(w, "request failed", http.StatusInternalServerError)
+					return
+				}
+				writeJSONResponse(w, logger, KnowledgeBaseReloadResponse{TitleCount: n})
+			})
+	}
+
 	srv := http.Server{
 		Addr:         fmt.Sprintf(":%d", application.Cfg.Port),
 		ReadTimeout:  2 * time.Second,
@@ -313,16 +660,68 @@ func main() {
 	}
 }
 
-func appLinkURL(dt time.Time, articleURL string) (string, error) {
-	parsedContentURL, err := url.Parse(articleURL)
-	if err != nil {
-		return "", err
+// adminOnly rejects requests without an "Authorization: Bearer <token>" header carrying the admin token.
+func adminOnly(token string) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			given := r.Header.Get("Authorization")
+			if subtle.ConstantTimeCompare([]byte(given), []byte("Bearer "+token)) != 1 {
+				http.Error(w, "unauthorized", http.StatusUnauthorized)
+				return
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+func writeTriviaError(w http.ResponseWriter, logger *zap.Logger, err error) {
+	switch {
+	case errors.Is(err, ErrCategoryFilterUnsupported),
+		errors.Is(err, ErrCategoryFilterLanguage),
+		errors.Is(err, ErrLanguageUnsupported):
+		http.Error(w, err.Error(), http.StatusBadRequest)
+	case errors.Is(err, repository.ErrNotFound):
+		http.Error(w, "no articles found", http.StatusNotFound)
+	default:
+		logger.Error(err.Error(), zap.Error(err))
+		http.Error(w, "request failed", http.StatusInternalServerError)
 	}
-	parsedURL := strings.Split(parsedContentURL.Path, "/")
-	urlTitle := parsedURL[len(parsedURL)-1]
-	appLink, err := url.JoinPath("/on-this-day/events/", dt.Format(time.DateOnly), urlTitle)
+}
+
+func writeRandomTriviaResponse(w http.ResponseWriter, logger *zap.Logger, resp RandomTriviaResponse) {
+	writeJSONResponse(w, logger, resp)
+}
+
+func writeJSONResponse(w http.ResponseWriter, logger *zap.Logger, resp any) {
+	b, err := json.Marshal(resp)
 	if err != nil {
-		return "", err
+		logger.Error(err.Error(),
+			zap.Error(err),
+			zap.String("operationDetail", "jsonMarshal"))
+		http.Error(w, "request failed", http.StatusInternalServerError)
+		return
+	}
+	if _, err := w.Write(b); err != nil {
+		logger.Error(err.Error(),
+			zap.Error(err),
+			zap.String("operationDetail", "responseWrite"))
+		http.Error(w, "request failed", http.StatusInternalServerError)
+		return
+	}
+}
+
+// requestLanguage returns the language of the lang query parameter, the default language when absent.
+// Languages that are not served are rejected with a bad request error.
+func requestLanguage(w http.ResponseWriter, r *http.Request, cfg app.Configuration) (string, bool) {
+	lang := strings.ToLower(strings.TrimSpace(r.URL.Query().Get("lang")))
+	if lang == "" {
+		return cfg.DefaultLanguage(), true
+	}
+	if !slices.Contains(cfg.Languages, lang) {
+		http.Error(w,
+			fmt.Sprintf("lang must be one of %s", strings.Join(cfg.Languages, ", ")),
+			http.StatusBadRequest)
+		return "", false
 	}
-	return appLink, nil
+	return lang, true
 }
@@ -0,0 +1,8 @@
+// Package migrations holds the database schema migrations.
+// Postgres migrations are applied externally, SQLite migrations are applied on startup.
+package migrations
+
+import "embed"
+
+//go:embed sqlite/*.up.sql
+var SQLite embed.FS
@@ -0,0 +1 @@
+DROP TABLE IF EXISTS wikipedia_title_categories;
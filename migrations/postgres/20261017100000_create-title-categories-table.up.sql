@@ -0,0 +1,11 @@
+CREATE TABLE wikipedia_title_categories (
+  title VARCHAR(255) NOT NULL,
+  category VARCHAR(255) NOT NULL,
+  created_at
+      TIMESTAMP WITH TIME ZONE DEFAULT
+      CURRENT_TIMESTAMP NOT NULL,
+  PRIMARY KEY (title, category)
+);
+
+CREATE INDEX idx_wk_title_categories_category
+    ON wikipedia_title_categories USING btree (category);
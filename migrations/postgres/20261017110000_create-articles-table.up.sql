@@ -0,0 +1,27 @@
+CREATE TABLE articles (
+  title VARCHAR(255) PRIMARY KEY,
+  display_title VARCHAR(255) NOT NULL,
+  page_id BIGINT NOT NULL,
+  wikibase_item VARCHAR(64) NOT NULL DEFAULT '',
+  extract TEXT NOT NULL DEFAULT '',
+  description TEXT NOT NULL DEFAULT '',
+  url TEXT NOT NULL DEFAULT '',
+  thumbnail_url TEXT NOT NULL DEFAULT '',
+  thumbnail_width INTEGER NOT NULL DEFAULT 0,
+  thumbnail_height INTEGER NOT NULL DEFAULT 0,
+  original_image_url TEXT NOT NULL DEFAULT '',
+  original_image_width INTEGER NOT NULL DEFAULT 0,
+  original_image_height INTEGER NOT NULL DEFAULT 0,
+  categories JSONB NOT NULL DEFAULT '[]',
+  revision VARCHAR(64) NOT NULL DEFAULT '',
+  fetched_at TIMESTAMP WITH TIME ZONE NOT NULL,
+  created_at
+      TIMESTAMP WITH TIME ZONE DEFAULT
+      CURRENT_TIMESTAMP NOT NULL,
+  updated_at
+      TIMESTAMP WITH TIME ZONE DEFAULT
+      CURRENT_TIMESTAMP NOT NULL
+);
+
+CREATE INDEX idx_articles_fetched_at
+    ON articles USING btree (fetched_at);
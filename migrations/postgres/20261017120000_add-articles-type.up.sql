@@ -0,0 +1 @@
+ALTER TABLE articles ADD COLUMN type VARCHAR(32) NOT NULL DEFAULT '';
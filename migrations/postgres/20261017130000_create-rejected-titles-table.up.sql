@@ -0,0 +1,7 @@
+CREATE TABLE rejected_titles (
+  title VARCHAR(255) PRIMARY KEY,
+  reason VARCHAR(255) NOT NULL,
+  created_at
+      TIMESTAMP WITH TIME ZONE DEFAULT
+      CURRENT_TIMESTAMP NOT NULL
+);
@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS loader_runs;
+DROP TABLE IF EXISTS article_views;
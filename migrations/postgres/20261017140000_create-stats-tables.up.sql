@@ -0,0 +1,20 @@
+CREATE TABLE article_views (
+  title VARCHAR(255) PRIMARY KEY,
+  views BIGINT NOT NULL DEFAULT 0,
+  last_viewed_at
+      TIMESTAMP WITH TIME ZONE DEFAULT
+      CURRENT_TIMESTAMP NOT NULL
+);
+
+CREATE INDEX idx_article_views_views
+    ON article_views USING btree (views DESC);
+
+CREATE TABLE loader_runs (
+  id BIGSERIAL PRIMARY KEY,
+  title_count BIGINT NOT NULL,
+  started_at TIMESTAMP WITH TIME ZONE NOT NULL,
+  completed_at TIMESTAMP WITH TIME ZONE NOT NULL
+);
+
+CREATE INDEX idx_loader_runs_completed_at
+    ON loader_runs USING btree (completed_at);
@@ -0,0 +1,11 @@
+CREATE TABLE session_seen_titles (
+  session_id VARCHAR(64) NOT NULL,
+  title VARCHAR(255) NOT NULL,
+  created_at
+      TIMESTAMP WITH TIME ZONE DEFAULT
+      CURRENT_TIMESTAMP NOT NULL,
+  PRIMARY KEY (session_id, title)
+);
+
+CREATE INDEX idx_session_seen_titles_created_at
+    ON session_seen_titles USING btree (created_at);
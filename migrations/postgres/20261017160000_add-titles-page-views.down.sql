@@ -0,0 +1,3 @@
+DROP INDEX IF EXISTS idx_wk_titles_page_views;
+
+ALTER TABLE wikipedia_titles DROP COLUMN IF EXISTS page_views;
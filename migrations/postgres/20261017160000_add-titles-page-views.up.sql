@@ -0,0 +1,4 @@
+ALTER TABLE wikipedia_titles ADD COLUMN page_views BIGINT NOT NULL DEFAULT 0;
+
+CREATE INDEX idx_wk_titles_page_views
+    ON wikipedia_titles USING btree (page_views) WHERE page_views > 0;
@@ -0,0 +1,10 @@
+ALTER TABLE wikipedia_titles ADD COLUMN numeric_id BIGSERIAL;
+
+CREATE INDEX idx_wk_titles_numeric_id
+    ON wikipedia_titles USING btree (numeric_id);
+
+DROP TABLE IF EXISTS wikipedia_title_counter;
+
+DROP INDEX IF EXISTS idx_wk_titles_ordinal;
+
+ALTER TABLE wikipedia_titles DROP COLUMN IF EXISTS ordinal;
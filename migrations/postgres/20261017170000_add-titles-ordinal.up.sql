@@ -0,0 +1,27 @@
+ALTER TABLE wikipedia_titles ADD COLUMN ordinal BIGINT;
+
+UPDATE wikipedia_titles t SET ordinal = o.ordinal
+FROM (
+  SELECT id, ROW_NUMBER() OVER (ORDER BY numeric_id, title) AS ordinal
+  FROM wikipedia_titles
+) o
+WHERE t.id = o.id;
+
+ALTER TABLE wikipedia_titles ALTER COLUMN ordinal SET NOT NULL;
+
+CREATE UNIQUE INDEX idx_wk_titles_ordinal
+    ON wikipedia_titles USING btree (ordinal);
+
+-- Single row holding the highest ordinal, so that titles are counted in constant time
+-- and concurrent loaders assign ordinals without gaps.
+CREATE TABLE wikipedia_title_counter (
+  id SMALLINT PRIMARY KEY DEFAULT 1 CHECK (id = 1),
+  value BIGINT NOT NULL
+);
+
+INSERT INTO wikipedia_title_counter (id, value)
+SELECT 1, COUNT(*) FROM wikipedia_titles;
+
+DROP INDEX IF EXISTS idx_wk_titles_numeric_id;
+
+ALTER TABLE wikipedia_titles DROP COLUMN numeric_id;
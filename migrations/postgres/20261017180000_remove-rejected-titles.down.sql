@@ -0,0 +1,9 @@
+-- Restore the rejected titles into wikipedia_titles, appended after the highest ordinal.
+-- Rejected titles stay recorded in rejected_titles, so that they remain excluded from selection.
+INSERT INTO wikipedia_titles (id, title, ordinal)
+SELECT gen_random_uuid()::text, r.title, c.value + ROW_NUMBER() OVER (ORDER BY r.title)
+FROM rejected_titles r
+CROSS JOIN wikipedia_title_counter c
+WHERE NOT EXISTS (SELECT 1 FROM wikipedia_titles t WHERE t.title = r.title);
+
+UPDATE wikipedia_title_counter SET value = (SELECT COUNT(*) FROM wikipedia_titles);
@@ -0,0 +1,17 @@
+-- Rejected titles are now removed from wikipedia_titles, instead of being filtered out on selection.
+DELETE FROM wikipedia_titles t
+USING rejected_titles r
+WHERE t.title = r.title;
+
+-- Renumber the remaining titles, keeping ordinals dense. Ordinals are negated first,
+-- so that the unique index does not conflict while they are reassigned.
+UPDATE wikipedia_titles SET ordinal = -ordinal;
+
+UPDATE wikipedia_titles t SET ordinal = o.ordinal
+FROM (
+  SELECT id, ROW_NUMBER() OVER (ORDER BY -ordinal) AS ordinal
+  FROM wikipedia_titles
+) o
+WHERE t.id = o.id;
+
+UPDATE wikipedia_title_counter SET value = (SELECT COUNT(*) FROM wikipedia_titles);
@@ -0,0 +1 @@
+DROP INDEX IF EXISTS idx_wk_titles_title_trgm;
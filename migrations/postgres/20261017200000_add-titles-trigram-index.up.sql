@@ -0,0 +1,5 @@
+CREATE EXTENSION IF NOT EXISTS pg_trgm;
+
+-- Serves both the case-insensitive prefix matches and the similarity matches of title search.
+CREATE INDEX idx_wk_titles_title_trgm
+    ON wikipedia_titles USING gin (title gin_trgm_ops);
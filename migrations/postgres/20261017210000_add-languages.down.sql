@@ -0,0 +1,39 @@
+DELETE FROM articles WHERE lang <> 'en';
+
+ALTER TABLE articles DROP CONSTRAINT articles_pkey;
+
+ALTER TABLE articles ADD PRIMARY KEY (title);
+
+ALTER TABLE articles DROP COLUMN lang;
+
+DELETE FROM rejected_titles WHERE lang <> 'en';
+
+ALTER TABLE rejected_titles DROP CONSTRAINT rejected_titles_pkey;
+
+ALTER TABLE rejected_titles ADD PRIMARY KEY (title);
+
+ALTER TABLE rejected_titles DROP COLUMN lang;
+
+DELETE FROM wikipedia_title_counter WHERE lang <> 'en';
+
+ALTER TABLE wikipedia_title_counter DROP CONSTRAINT wikipedia_title_counter_pkey;
+
+ALTER TABLE wikipedia_title_counter ADD COLUMN id SMALLINT NOT NULL DEFAULT 1 CHECK (id = 1);
+
+ALTER TABLE wikipedia_title_counter ADD PRIMARY KEY (id);
+
+ALTER TABLE wikipedia_title_counter DROP COLUMN lang;
+
+DELETE FROM wikipedia_titles WHERE lang <> 'en';
+
+DROP INDEX IF EXISTS idx_wk_titles_lang_ordinal;
+
+CREATE UNIQUE INDEX idx_wk_titles_ordinal
+    ON wikipedia_titles USING btree (ordinal);
+
+DROP INDEX IF EXISTS idx_wk_titles_lang_title;
+
+CREATE UNIQUE INDEX idx_wk_titles_title
+    ON wikipedia_titles USING btree (title);
+
+ALTER TABLE wikipedia_titles DROP COLUMN lang;
@@ -0,0 +1,33 @@
+-- Titles, their counters, rejections and articles are kept per Wikipedia edition language.
+-- Existing rows belong to the English Wikipedia.
+ALTER TABLE wikipedia_titles ADD COLUMN lang VARCHAR(16) NOT NULL DEFAULT 'en';
+
+DROP INDEX IF EXISTS idx_wk_titles_title;
+
+CREATE UNIQUE INDEX idx_wk_titles_lang_title
+    ON wikipedia_titles USING btree (lang, title);
+
+DROP INDEX IF EXISTS idx_wk_titles_ordinal;
+
+CREATE UNIQUE INDEX idx_wk_titles_lang_ordinal
+    ON wikipedia_titles USING btree (lang, ordinal);
+
+ALTER TABLE wikipedia_title_counter ADD COLUMN lang VARCHAR(16) NOT NULL DEFAULT 'en';
+
+ALTER TABLE wikipedia_title_counter DROP CONSTRAINT wikipedia_title_counter_pkey;
+
+ALTER TABLE wikipedia_title_counter DROP COLUMN id;
+
+ALTER TABLE wikipedia_title_counter ADD PRIMARY KEY (lang);
+
+ALTER TABLE rejected_titles ADD COLUMN lang VARCHAR(16) NOT NULL DEFAULT 'en';
+
+ALTER TABLE rejected_titles DROP CONSTRAINT rejected_titles_pkey;
+
+ALTER TABLE rejected_titles ADD PRIMARY KEY (lang, title);
+
+ALTER TABLE articles ADD COLUMN lang VARCHAR(16) NOT NULL DEFAULT 'en';
+
+ALTER TABLE articles DROP CONSTRAINT articles_pkey;
+
+ALTER TABLE articles ADD PRIMARY KEY (lang, title);
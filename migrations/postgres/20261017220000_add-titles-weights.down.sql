@@ -0,0 +1,7 @@
+ALTER TABLE wikipedia_title_counter DROP COLUMN IF EXISTS total_weight;
+
+DROP INDEX IF EXISTS idx_wk_titles_lang_weight_to;
+
+ALTER TABLE wikipedia_titles DROP COLUMN IF EXISTS weight_to;
+
+ALTER TABLE wikipedia_titles DROP COLUMN IF EXISTS weight_from;
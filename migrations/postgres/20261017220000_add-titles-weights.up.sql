@@ -0,0 +1,11 @@
+-- Titles with page views own the interval (weight_from, weight_to] of the cumulative
+-- selection weights of their language, computed by the loader. Weighted selection draws
+-- a number up to the total weight and looks up the interval holding it.
+ALTER TABLE wikipedia_titles ADD COLUMN weight_from DOUBLE PRECISION;
+
+ALTER TABLE wikipedia_titles ADD COLUMN weight_to DOUBLE PRECISION;
+
+CREATE INDEX idx_wk_titles_lang_weight_to
+    ON wikipedia_titles USING btree (lang, weight_to);
+
+ALTER TABLE wikipedia_title_counter ADD COLUMN total_weight DOUBLE PRECISION NOT NULL DEFAULT 0;
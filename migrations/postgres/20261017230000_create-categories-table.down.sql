@@ -0,0 +1 @@
+DROP TABLE IF EXISTS wikipedia_categories;
@@ -0,0 +1,4 @@
+CREATE TABLE wikipedia_categories (
+  category VARCHAR(255) PRIMARY KEY,
+  members_loaded_at TIMESTAMP WITH TIME ZONE NOT NULL
+);
@@ -0,0 +1,11 @@
+DELETE FROM session_seen_titles WHERE LENGTH(title) > 255;
+
+ALTER TABLE session_seen_titles ALTER COLUMN title TYPE VARCHAR(255);
+
+DELETE FROM article_views WHERE lang <> 'en';
+
+ALTER TABLE article_views DROP CONSTRAINT article_views_pkey;
+
+ALTER TABLE article_views ADD PRIMARY KEY (title);
+
+ALTER TABLE article_views DROP COLUMN lang;
@@ -0,0 +1,9 @@
+-- Article views are counted per Wikipedia edition language. Existing rows belong to the English Wikipedia.
+ALTER TABLE article_views ADD COLUMN lang VARCHAR(16) NOT NULL DEFAULT 'en';
+
+ALTER TABLE article_views DROP CONSTRAINT article_views_pkey;
+
+ALTER TABLE article_views ADD PRIMARY KEY (lang, title);
+
+-- Seen titles are prefixed with their language, such as "de:Berlin".
+ALTER TABLE session_seen_titles ALTER COLUMN title TYPE VARCHAR(300);
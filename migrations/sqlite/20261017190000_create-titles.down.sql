@@ -0,0 +1,5 @@
+DROP TABLE IF EXISTS rejected_titles;
+
+DROP TABLE IF EXISTS wikipedia_title_counter;
+
+DROP TABLE IF EXISTS wikipedia_titles;
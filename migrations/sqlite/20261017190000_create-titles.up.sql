@@ -0,0 +1,38 @@
+CREATE TABLE wikipedia_titles (
+  id VARCHAR(255) PRIMARY KEY,
+  title VARCHAR(255) NOT NULL,
+  ordinal BIGINT NOT NULL,
+  page_views BIGINT NOT NULL DEFAULT 0,
+  created_at
+      TIMESTAMP DEFAULT
+      CURRENT_TIMESTAMP NOT NULL,
+  updated_at
+      TIMESTAMP DEFAULT
+      CURRENT_TIMESTAMP NOT NULL
+);
+
+CREATE UNIQUE INDEX idx_wk_titles_title
+    ON wikipedia_titles (title);
+
+CREATE UNIQUE INDEX idx_wk_titles_ordinal
+    ON wikipedia_titles (ordinal);
+
+CREATE INDEX idx_wk_titles_page_views
+    ON wikipedia_titles (page_views)
+    WHERE page_views > 0;
+
+-- Single row holding the highest ordinal, see the Postgres migration of the same name.
+CREATE TABLE wikipedia_title_counter (
+  id SMALLINT PRIMARY KEY DEFAULT 1 CHECK (id = 1),
+  value BIGINT NOT NULL
+);
+
+INSERT INTO wikipedia_title_counter (id, value) VALUES (1, 0);
+
+CREATE TABLE rejected_titles (
+  title VARCHAR(255) PRIMARY KEY,
+  reason VARCHAR(255) NOT NULL,
+  created_at
+      TIMESTAMP DEFAULT
+      CURRENT_TIMESTAMP NOT NULL
+);
@@ -0,0 +1,40 @@
+CREATE TABLE rejected_titles_single (
+  title VARCHAR(255) PRIMARY KEY,
+  reason VARCHAR(255) NOT NULL,
+  created_at
+      TIMESTAMP DEFAULT
+      CURRENT_TIMESTAMP NOT NULL
+);
+
+INSERT INTO rejected_titles_single (title, reason, created_at)
+SELECT title, reason, created_at FROM rejected_titles WHERE lang = 'en';
+
+DROP TABLE rejected_titles;
+
+ALTER TABLE rejected_titles_single RENAME TO rejected_titles;
+
+CREATE TABLE wikipedia_title_counter_single (
+  id SMALLINT PRIMARY KEY DEFAULT 1 CHECK (id = 1),
+  value BIGINT NOT NULL
+);
+
+INSERT INTO wikipedia_title_counter_single (id, value)
+SELECT 1, COALESCE((SELECT value FROM wikipedia_title_counter WHERE lang = 'en'), 0);
+
+DROP TABLE wikipedia_title_counter;
+
+ALTER TABLE wikipedia_title_counter_single RENAME TO wikipedia_title_counter;
+
+DELETE FROM wikipedia_titles WHERE lang <> 'en';
+
+DROP INDEX idx_wk_titles_lang_ordinal;
+
+CREATE UNIQUE INDEX idx_wk_titles_ordinal
+    ON wikipedia_titles (ordinal);
+
+DROP INDEX idx_wk_titles_lang_title;
+
+CREATE UNIQUE INDEX idx_wk_titles_title
+    ON wikipedia_titles (title);
+
+ALTER TABLE wikipedia_titles DROP COLUMN lang;
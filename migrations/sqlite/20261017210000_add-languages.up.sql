@@ -0,0 +1,43 @@
+-- Titles, their counters and rejections are kept per Wikipedia edition language,
+-- see the Postgres migration of the same name. SQLite cannot alter primary keys,
+-- so the counter and rejection tables are recreated.
+ALTER TABLE wikipedia_titles ADD COLUMN lang VARCHAR(16) NOT NULL DEFAULT 'en';
+
+DROP INDEX idx_wk_titles_title;
+
+CREATE UNIQUE INDEX idx_wk_titles_lang_title
+    ON wikipedia_titles (lang, title);
+
+DROP INDEX idx_wk_titles_ordinal;
+
+CREATE UNIQUE INDEX idx_wk_titles_lang_ordinal
+    ON wikipedia_titles (lang, ordinal);
+
+CREATE TABLE wikipedia_title_counter_languages (
+  lang VARCHAR(16) PRIMARY KEY,
+  value BIGINT NOT NULL
+);
+
+INSERT INTO wikipedia_title_counter_languages (lang, value)
+SELECT 'en', value FROM wikipedia_title_counter;
+
+DROP TABLE wikipedia_title_counter;
+
+ALTER TABLE wikipedia_title_counter_languages RENAME TO wikipedia_title_counter;
+
+CREATE TABLE rejected_titles_languages (
+  lang VARCHAR(16) NOT NULL,
+  title VARCHAR(255) NOT NULL,
+  reason VARCHAR(255) NOT NULL,
+  created_at
+      TIMESTAMP DEFAULT
+      CURRENT_TIMESTAMP NOT NULL,
+  PRIMARY KEY (lang, title)
+);
+
+INSERT INTO rejected_titles_languages (lang, title, reason, created_at)
+SELECT 'en', title, reason, created_at FROM rejected_titles;
+
+DROP TABLE rejected_titles;
+
+ALTER TABLE rejected_titles_languages RENAME TO rejected_titles;
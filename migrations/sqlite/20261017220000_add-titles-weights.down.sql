@@ -0,0 +1,7 @@
+ALTER TABLE wikipedia_title_counter DROP COLUMN total_weight;
+
+DROP INDEX IF EXISTS idx_wk_titles_lang_weight_to;
+
+ALTER TABLE wikipedia_titles DROP COLUMN weight_to;
+
+ALTER TABLE wikipedia_titles DROP COLUMN weight_from;
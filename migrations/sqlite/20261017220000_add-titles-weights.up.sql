@@ -0,0 +1,9 @@
+-- Cumulative selection weights, see the Postgres migration of the same name.
+ALTER TABLE wikipedia_titles ADD COLUMN weight_from DOUBLE PRECISION;
+
+ALTER TABLE wikipedia_titles ADD COLUMN weight_to DOUBLE PRECISION;
+
+CREATE INDEX idx_wk_titles_lang_weight_to
+    ON wikipedia_titles (lang, weight_to);
+
+ALTER TABLE wikipedia_title_counter ADD COLUMN total_weight DOUBLE PRECISION NOT NULL DEFAULT 0;
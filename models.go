@@ -1,5 +1,7 @@
 package main
 
+import "time"
+
 type Image struct {
 	URL    string `json:"url"`
 	Width  int    `json:"width"`
@@ -18,10 +20,29 @@ type WikiSummary struct {
 	Type       string              `json:"type"`
 	Categories []string            `json:"categories"`
 	Metadata   WikiSummaryMetadata `json:"metadata"`
+	// AppLinkURL is the permalink in Knowledge Leaf
+	AppLinkURL string `json:"app_link_url"`
+	// fetchedAt is the time the article was retrieved from Wikipedia
+	fetchedAt time.Time
+	// lang is the language of the Wikipedia edition of the article
+	lang string
 }
 
 type RandomTriviaResponse struct {
 	Results []WikiSummary `json:"results"`
+	Errors  []TriviaError `json:"errors,omitempty"`
+}
+
+type NextTriviaResponse struct {
+	Result WikiSummary `json:"result"`
+	// Path is the breadcrumb of the titles walked so far, ending with the result title
+	Path []string `json:"path"`
+}
+
+// TriviaError describes an article that could not be included in the results.
+type TriviaError struct {
+	Title string `json:"title,omitempty"`
+	Error string `json:"error"`
 }
 
 type OnThisDayEvent struct {
@@ -45,3 +66,102 @@ type OnThisDayEventReference struct {
 type EventsOnThisDayResponse struct {
 	Titles []OnThisDayEvent `json:"titles"`
 }
+
+type QuizQuestion struct {
+	// Token identifies the question and carries the encrypted answer
+	Token      string   `json:"token"`
+	Question   string   `json:"question"`
+	Extract    string   `json:"extract"`
+	Categories []string `json:"categories"`
+	Options    []string `json:"options"`
+}
+
+type QuizAnswerRequest struct {
+	Token  string `json:"token"`
+	Answer string `json:"answer"`
+}
+
+type QuizAnswerResponse struct {
+	Correct bool   `json:"correct"`
+	Answer  string `json:"answer"`
+	URL     string `json:"url"`
+}
+
+type TriviaStatsResponse struct {
+	Titles TitleStats  `json:"titles"`
+	Loader *LoaderRun  `json:"loader"`
+	Views  *ViewsStats `json:"views"`
+}
+
+type TitleStats struct {
+	Backend string `json:"backend"`
+	Count   int64  `json:"count"`
+}
+
+type LoaderRun struct {
+	TitleCount  int64     `json:"title_count"`
+	StartedAt   time.Time `json:"started_at"`
+	CompletedAt time.Time `json:"completed_at"`
+}
+
+type ViewsStats struct {
+	Total    int64          `json:"total"`
+	Articles []ArticleViews `json:"articles"`
+}
+
+type ArticleViews struct {
+	Lang  string `json:"lang"`
+	Title string `json:"title"`
+	Views int64  `json:"views"`
+}
+
+type KnowledgeBaseReloadResponse struct {
+	TitleCount int `json:"title_count"`
+}
+
+type TitleSearchResult struct {
+	Title string `json:"title"`
+	// AppLinkURL is the permalink in Knowledge Leaf
+	AppLinkURL string `json:"app_link_url"`
+}
+
+type TitleSearchResponse struct {
+	Results []TitleSearchResult `json:"results"`
+	// NextOffset is the offset of the next page, omitted on the last page
+	NextOffset *int `json:"next_offset,omitempty"`
+}
+
+type FeaturedResponse struct {
+	Date string `json:"date"`
+	// Article is the featured article of the day, omitted when the Wikipedia edition has none
+	Article  *WikiSummary       `json:"article,omitempty"`
+	MostRead []MostReadArticle  `json:"most_read"`
+	Picture  *FeaturedPicture   `json:"picture,omitempty"`
+	News     []FeaturedNewsItem `json:"news"`
+	// AppLinkURL is the permalink in Knowledge Leaf
+	AppLinkURL string `json:"app_link_url"`
+}
+
+type MostReadArticle struct {
+	WikiSummary
+	Views int64 `json:"views"`
+	Rank  int   `json:"rank"`
+}
+
+type FeaturedPicture struct {
+	Title       string `json:"title"`
+	Description string `json:"description"`
+	Artist      string `json:"artist"`
+	Credit      string `json:"credit"`
+	License     string `json:"license"`
+	LicenseURL  string `json:"license_url"`
+	Image       Image  `json:"image"`
+	Thumbnail   Image  `json:"thumbnail"`
+	URL         string `json:"url"`
+}
+
+type FeaturedNewsItem struct {
+	// Story is the plain text of the news story
+	Story    string        `json:"story"`
+	Articles []WikiSummary `json:"articles"`
+}
@@ -0,0 +1,224 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+	// Timezones are resolved even when the host has no timezone database
+	_ "time/tzdata"
+
+	"go.uber.org/zap"
+	"golang.org/x/sync/singleflight"
+
+	"knowledgeleaf/app"
+	"knowledgeleaf/externalapi/wikipedia"
+	"knowledgeleaf/repository"
+)
+
+// onThisDayFeeds serves the on this day feeds from the in-process cache, then from the shared cache.
+// Entries expire at the next UTC day boundary. Concurrent misses of a feed share one upstream request.
+type onThisDayFeeds struct {
+	local  repository.FeedCache
+	shared repository.FeedCache
+	group  singleflight.Group
+	// timeout bounds the upstream requests, which outlive the request that started them
+	timeout time.Duration
+}
+
+func newOnThisDayFeeds(application app.App) *onThisDayFeeds {
+	return &onThisDayFeeds{
+		local:   repository.NewMemoryFeedCache(),
+		shared:  application.FeedCache,
+		timeout: application.Cfg.RequestTimeout,
+	}
+}
+
+// Entries returns the entries of a feed type for the month and day of day, in the given language.
+func (f *onThisDayFeeds) Entries(
+	ctx context.Context,
+	lang string,
+	feedType string,
+	day time.Time,
+) ([]wikipedia.RestV1OnThisDayEntry, error) {
+	key := fmt.Sprintf("onthisday:%s:%s:%s", lang, feedType, day.Format("01-02"))
+	b, err := f.local.Get(ctx, key)
+	if errors.Is(err, repository.ErrNotFound) {
+		ch := f.group.DoChan(key, func() (any, error) {
+			fetchCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), f.timeout)
+			defer cancel()
+			return f.fetch(fetchCtx, key, lang, feedType, day)
+		})
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case res := <-ch:
+			if res.Err != nil {
+				return nil, res.Err
+			}
+			b, err = res.Val.([]byte), nil
+		}
+	}
+	if err != nil {
+		return nil, err
+	}
+	var entries []wikipedia.RestV1OnThisDayEntry
+	if err := json.Unmarshal(b, &entries); err != nil {
+		return nil, err
+	}
+	return entries, nil
+}
+
+// fetch reads a feed from the shared cache, or from Wikipedia, and caches it.
+func (f *onThisDayFeeds) fetch(ctx context.Context, key string, lang string, feedType string, day time.Time) ([]byte, error) {
+	logger := app.LoggerFromContext(ctx)
+	now := time.Now().UTC()
+	expiresAt := time.Date(now.Year(), now.Month(), now.Day()+1, 0, 0, 0, 0, time.UTC)
+	if f.shared != nil {
+		b, err := f.shared.Get(ctx, key)
+		switch {
+		case err == nil:
+			return b, f.local.Set(ctx, key, b, expiresAt)
+		case !errors.Is(err, repository.ErrNotFound):
+			logger.Warn("reading cached feed failed", zap.Error(err), zap.String("key", key))
+		}
+	}
+
+	entries, err := wikipedia.NewLanguageClient(lang).OnThisDay(ctx, feedType, day)
+	if err != nil {
+		return nil, err
+	}
+	b, err := json.Marshal(entries)
+	if err != nil {
+		return nil, err
+	}
+	if f.shared != nil {
+		if err := f.shared.Set(ctx, key, b, expiresAt); err != nil {
+			logger.Warn("caching feed failed", zap.Error(err), zap.String("key", key))
+		}
+	}
+	return b, f.local.Set(ctx, key, b, expiresAt)
+}
+
+// timezoneHeader carries the IANA timezone of the client, as an alternative to the tz query parameter.
+const timezoneHeader = "X-Timezone"
+
+// onThisDayDay returns the day whose on this day feeds are requested: the MM-DD date query parameter,
+// or today in the timezone of the client, UTC by default. The year is the current one, or the latest
+// leap year for February 29.
+func onThisDayDay(r *http.Request, now time.Time) (time.Time, error) {
+	tz := r.URL.Query().Get("tz")
+	if tz == "" {
+		tz = r.Header.Get(timezoneHeader)
+	}
+	loc := time.UTC
+	if tz != "" {
+		var err error
+		if loc, err = time.LoadLocation(tz); err != nil {
+			return time.Time{}, errors.New("tz must be an IANA timezone, such as Europe/Paris")
+		}
+	}
+	now = now.In(loc)
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC)
+
+	v := r.URL.Query().Get("date")
+	if v == "" {
+		return today, nil
+	}
+	// Year 0 is a leap year, so that February 29 is accepted
+	md, err := time.Parse("01-02", v)
+	if err != nil {
+		return time.Time{}, errors.New("date must be formatted as MM-DD")
+	}
+	for year := today.Year(); ; year-- {
+		day := time.Date(year, md.Month(), md.Day(), 0, 0, 0, 0, time.UTC)
+		if day.Month() == md.Month() {
+			return day, nil
+		}
+	}
+}
+
+// onThisDayDate returns the date of an entry of the feed of the given day. Negative years are BCE years.
+// Holidays have no year, they are dated on the given day.
+func onThisDayDate(feedType string, year int, day time.Time) (time.Time, error) {
+	if feedType == wikipedia.OnThisDayHolidays {
+		return day, nil
+	}
+	hasNegativeYear := year < 0
+	if hasNegativeYear {
+		year *= -1
+	}
+	dt, err := time.Parse(time.DateOnly, fmt.Sprintf("%04d-%s", year, day.Format("01-02")))
+	if err != nil {
+		return time.Time{}, err
+	}
+	if hasNegativeYear {
+		dt = dt.AddDate(-2*year, 0, 0)
+	}
+	return dt, nil
+}
+
+// newOnThisDayEvent converts an entry with at least one page, dated on dt, to an event.
+func newOnThisDayEvent(
+	lang string,
+	feedType string,
+	entry wikipedia.RestV1OnThisDayEntry,
+	dt time.Time,
+) (OnThisDayEvent, error) {
+	mainPage := entry.Pages[0]
+	var references []OnThisDayEventReference
+	if len(entry.Pages) > 1 {
+		for _, p := range entry.Pages[1:] {
+			references = append(references, OnThisDayEventReference{
+				Title: p.Title,
+				URL:   p.ContentUrls.Desktop.Page,
+			})
+		}
+	}
+	appLink, err := appLinkURL(lang, feedType, dt, mainPage.ContentUrls.Desktop.Page)
+	if err != nil {
+		return OnThisDayEvent{}, err
+	}
+	return OnThisDayEvent{
+		Title:      entry.Text,
+		ShortTitle: mainPage.Titles.Normalized,
+		Image: Image{
+			URL:    mainPage.Thumbnail.Source,
+			Width:  mainPage.Thumbnail.Width,
+			Height: mainPage.Thumbnail.Height,
+		},
+		Description: mainPage.Description,
+		Extract:     mainPage.Extract,
+		URL:         mainPage.ContentUrls.Desktop.Page,
+		References:  references,
+		Year:        entry.Year,
+		AppLinkURL:  appLink,
+	}, nil
+}
+
+// pageURLTitle returns the title segment of a Wikipedia page URL.
+func pageURLTitle(articleURL string) (string, error) {
+	parsedContentURL, err := url.Parse(articleURL)
+	if err != nil {
+		return "", err
+	}
+	parsedURL := strings.Split(parsedContentURL.Path, "/")
+	return parsedURL[len(parsedURL)-1], nil
+}
+
+// appLinkURL returns the Knowledge Leaf permalink of an on this day entry, in the given language.
+func appLinkURL(lang string, feedType string, dt time.Time, articleURL string) (string, error) {
+	urlTitle, err := pageURLTitle(articleURL)
+	if err != nil {
+		return "", err
+	}
+	appLink, err := url.JoinPath("/on-this-day/", feedType, dt.Format(time.DateOnly), urlTitle)
+	if err != nil {
+		return "", err
+	}
+	return appLink + "?" + url.Values{"lang": {lang}}.Encode(), nil
+}
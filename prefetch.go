@@ -0,0 +1,97 @@
+package main
+
+import (
+	"context"
+	"time"
+
+	"go.uber.org/zap"
+
+	"knowledgeleaf/app"
+)
+
+const (
+	prefetchMinBackoff = time.Second
+	prefetchMaxBackoff = 30 * time.Second
+)
+
+// TriviaPool keeps a bounded buffer of fully resolved articles, so that random trivia
+// requests can be served without waiting for the Wikipedia APIs.
+// The buffer is refilled concurrently by background workers. Articles fetched longer
+// than the configured article max age ago are discarded instead of being served.
+type TriviaPool struct {
+	triviaBackend *RandomTriviaBackend
+	summaries     chan WikiSummary
+	workers       int
+	maxAge        time.Duration
+}
+
+func NewTriviaPool(triviaBackend *RandomTriviaBackend, size, workers int) *TriviaPool {
+	return &TriviaPool{
+		triviaBackend: triviaBackend,
+		summaries:     make(chan WikiSummary, size),
+		workers:       max(workers, 1),
+		maxAge:        triviaBackend.application.Cfg.ArticleMaxAge,
+	}
+}
+
+// Start launches the refill workers, which run until the context is cancelled.
+func (p *TriviaPool) Start(ctx context.Context) {
+	for i := 0; i < p.workers; i++ {
+		go p.refill(ctx)
+	}
+}
+
+// Pop returns a prefetched article, if one is available. It never blocks.
+// Stale articles are dropped, freeing their slot for the refill workers.
+func (p *TriviaPool) Pop() (WikiSummary, bool) {
+	if p == nil {
+		return WikiSummary{}, false
+	}
+	for {
+		select {
+		case summary := <-p.summaries:
+			if p.maxAge > 0 && time.Since(summary.fetchedAt) > p.maxAge {
+				continue
+			}
+			return summary, true
+		default:
+			return WikiSummary{}, false
+		}
+	}
+}
+
+func (p *TriviaPool) refill(ctx context.Context) {
+	logger := p.triviaBackend.application.Logger.With(zap.String("operation", "trivia/prefetch"))
+	ctx = app.WithLogger(ctx, logger)
+	backoff := prefetchMinBackoff
+	for {
+		summaries, err := p.resolve(ctx)
+		if err != nil {
+			if ctx.Err() != nil {
+				return
+			}
+			logger.Warn("prefetching article failed", zap.Error(err), zap.Duration("backoff", backoff))
+			select {
+			case <-ctx.Done():
+				return
+			case <-time.After(backoff):
+			}
+			backoff = min(2*backoff, prefetchMaxBackoff)
+			continue
+		}
+		backoff = prefetchMinBackoff
+		for _, summary := range summaries {
+			select {
+			case <-ctx.Done():
+				return
+			case p.summaries <- summary:
+			}
+		}
+	}
+}
+
+func (p *TriviaPool) resolve(ctx context.Context) ([]WikiSummary, error) {
+	ctx, cancel := context.WithTimeout(ctx, p.triviaBackend.application.Cfg.RequestTimeout)
+	defer cancel()
+	return randomizeArticle(ctx, p.triviaBackend, TitleQuery{})
+}
@@ -0,0 +1,251 @@
+package main
+
+import (
+	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	mathrand "math/rand"
+	"regexp"
+	"strings"
+	"time"
+	"unicode"
+	"unicode/utf8"
+
+	"go.uber.org/zap"
+
+	"knowledgeleaf/app"
+	"knowledgeleaf/externalapi/wikipedia"
+)
+
+const (
+	quizQuestionText   = "Which article matches this extract?"
+	quizDistractors    = 3
+	quizRedaction      = "_____"
+	maxQuizCategories  = 3
+	maxQuizAnswerBytes = 4096
+)
+
+var (
+	ErrInvalidQuizToken = errors.New("invalid quiz token")
+	ErrExpiredQuizToken = errors.New("quiz token expired")
+)
+
+// quizTokenPayload is sealed into the question token, so that the answer
+// can neither be read nor altered by clients.
+type quizTokenPayload struct {
+	Answer    string `json:"a"`
+	URL       string `json:"u"`
+	ExpiresAt int64  `json:"e"`
+}
+
+// Quiz builds multiple-choice questions out of random articles.
+type Quiz struct {
+	triviaBackend *RandomTriviaBackend
+	aead          cipher.AEAD
+	tokenTTL      time.Duration
+}
+
+// NewQuiz creates a quiz whose tokens are encrypted with a key derived from secret.
+// Without a secret a random key is used, and tokens are only valid for this process.
+func NewQuiz(triviaBackend *RandomTriviaBackend, secret string, tokenTTL time.Duration) (*Quiz, error) {
+	key := make([]byte, sha256.Size)
+	if secret == "" {
+		triviaBackend.application.Logger.Warn("QUIZ_SECRET is not set - quiz tokens are only valid for this instance")
+		if _, err := rand.Read(key); err != nil {
+			return nil, err
+		}
+	} else {
+		sum := sha256.Sum256([]byte(secret))
+		key = sum[:]
+	}
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	aead, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, err
+	}
+	return &Quiz{triviaBackend: triviaBackend, aead: aead, tokenTTL: tokenTTL}, nil
+}
+
+// NewQuestion turns an article into a question, with distractor titles drawn from the article categories.
+func (q *Quiz) NewQuestion(ctx context.Context, summary WikiSummary) (QuizQuestion, error) {
+	answer := titleKey(summary.Title)
+	distractors := q.distractors(ctx, summary)
+	if len(distractors) < quizDistractors {
+		return QuizQuestion{}, fmt.Errorf("found %d distractors for %q", len(distractors), answer)
+	}
+	options := append(distractors, answer)
+	mathrand.Shuffle(len(options), func(i, j int) {
+		options[i], options[j] = options[j], options[i]
+	})
+
+	token, err := q.seal(quizTokenPayload{
+		Answer:    answer,
+		URL:       summary.Metadata.URL,
+		ExpiresAt: time.Now().Add(q.tokenTTL).Unix(),
+	})
+	if err != nil {
+		return QuizQuestion{}, err
+	}
+	return QuizQuestion{
+		Token:      token,
+		Question:   quizQuestionText,
+		Extract:    redactTitle(summary.Summary, answer),
+		Categories: quizCategories(summary.Categories, answer),
+		Options:    options,
+	}, nil
+}
+
+// CheckAnswer validates the question token and compares the answer against the sealed one.
+func (q *Quiz) CheckAnswer(token string, answer string) (QuizAnswerResponse, error) {
+	payload, err := q.open(token)
+	if err != nil {
+		return QuizAnswerResponse{}, err
+	}
+	if time.Now().Unix() > payload.ExpiresAt {
+		return QuizAnswerResponse{}, ErrExpiredQuizToken
+	}
+	return QuizAnswerResponse{
+		Correct: strings.EqualFold(titleKey(strings.TrimSpace(answer)), payload.Answer),
+		Answer:  payload.Answer,
+		URL:     payload.URL,
+	}, nil
+}
+
+// distractors collects other articles sharing a category with the answer,
+// completing them with random titles when the categories are too small.
+func (q *Quiz) distractors(ctx context.Context, summary WikiSummary) []string {
+	logger := app.LoggerFromContext(ctx)
+	answer := titleKey(summary.Title)
+	seen := map[string]struct{}{strings.ToLower(answer): {}}
+	var candidates []string
+	add := func(title string) {
+		title = titleKey(title)
+		if _, ok := seen[strings.ToLower(title)]; ok || isListPage(title) {
+			return
+		}
+		seen[strings.ToLower(title)] = struct{}{}
+		candidates = append(candidates, title)
+	}
+
+	// Quiz questions are drawn from the default language
+	client := wikipedia.NewLanguageClient(q.triviaBackend.application.Cfg.DefaultLanguage())
+	categories := append([]string(nil), summary.Categories...)
+	mathrand.Shuffle(len(categories), func(i, j int) {
+		categories[i], categories[j] = categories[j], categories[i]
+	})
+	for _, category := range categories[:min(len(categories), maxQuizCategories)] {
+		members, err := client.CategoryMembers(ctx, category)
+		if err != nil {
+			logger.Warn("fetching category members failed", zap.Error(err), zap.String("category", category))
+			continue
+		}
+		for _, member := range members {
+			add(member)
+		}
+		if len(candidates) >= 2*quizDistractors {
+			break
+		}
+	}
+	mathrand.Shuffle(len(candidates), func(i, j int) {
+		candidates[i], candidates[j] = candidates[j], candidates[i]
+	})
+	candidates = candidates[:min(len(candidates), quizDistractors)]
+
+	for i := 0; len(candidates) < quizDistractors && i < maxDistinctAttempts*quizDistractors; i++ {
+		title, err := q.triviaBackend.RandomTitle(ctx, TitleQuery{})
+		if err != nil {
+			logger.Warn("selecting random distractor failed", zap.Error(err))
+			break
+		}
+		add(title)
+	}
+	return candidates
+}
+
+func (q *Quiz) seal(payload quizTokenPayload) (string, error) {
+	b, err := json.Marshal(payload)
+	if err != nil {
+		return "", err
+	}
+	nonce := make([]byte, q.aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(q.aead.Seal(nonce, nonce, b, nil)), nil
+}
+
+func (q *Quiz) open(token string) (quizTokenPayload, error) {
+	b, err := base64.RawURLEncoding.DecodeString(token)
+	if err != nil || len(b) < q.aead.NonceSize() {
+		return quizTokenPayload{}, ErrInvalidQuizToken
+	}
+	nonce, sealed := b[:q.aead.NonceSize()], b[q.aead.NonceSize():]
+	plain, err := q.aead.Open(nil, nonce, sealed, nil)
+	if err != nil {
+		return quizTokenPayload{}, ErrInvalidQuizToken
+	}
+	var payload quizTokenPayload
+	if err := json.Unmarshal(plain, &payload); err != nil {
+		return quizTokenPayload{}, ErrInvalidQuizToken
+	}
+	return payload, nil
+}
+
+var parentheticalSuffix = regexp.MustCompile(`\s*\([^)]*\)$`)
+
+// quizCategories drops the categories named after the answer, such as "Films directed by X",
+// which would give the answer away.
+func quizCategories(categories []string, answer string) []string {
+	kept := make([]string, 0, len(categories))
+	for _, category := range categories {
+		if redactTitle(titleKey(category), answer) == titleKey(category) {
+			kept = append(kept, category)
+		}
+	}
+	return kept
+}
+
+// redactTitle hides the title, and the title without its disambiguation suffix, from the text.
+// Only whole words are hidden, so that a short title such as "Ant" leaves "elephants" untouched.
+func redactTitle(text string, title string) string {
+	for _, t := range []string{title, parentheticalSuffix.ReplaceAllString(title, "")} {
+		if t == "" {
+			continue
+		}
+		text = redactWords(text, regexp.MustCompile(`(?i)`+regexp.QuoteMeta(t)))
+	}
+	return text
+}
+
+// redactWords replaces the matches of pattern that are not part of a longer word.
+func redactWords(text string, pattern *regexp.Regexp) string {
+	var b strings.Builder
+	last := 0
+	for _, match := range pattern.FindAllStringIndex(text, -1) {
+		start, end := match[0], match[1]
+		before, _ := utf8.DecodeLastRuneInString(text[:start])
+		after, _ := utf8.DecodeRuneInString(text[end:])
+		if isWordRune(before) || isWordRune(after) {
+			continue
+		}
+		b.WriteString(text[last:start])
+		b.WriteString(quizRedaction)
+		last = end
+	}
+	b.WriteString(text[last:])
+	return b.String()
+}
+
+// isWordRune reports whether r is a letter or a digit, RuneError standing for the text boundaries.
+func isWordRune(r rune) bool {
+	return unicode.IsLetter(r) || unicode.IsNumber(r)
+}
@@ -0,0 +1,74 @@
+package main
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestRedactTitle(t *testing.T) {
+	tests := []struct {
+		text  string
+		title string
+		want  string
+	}{
+		{
+			text:  "The Ant is an important insect; elephants and ants.",
+			title: "Ant",
+			want:  "The _____ is an important insect; elephants and ants.",
+		},
+		{
+			text:  "Io is a moon. Iodine is not.",
+			title: "Io",
+			want:  "_____ is a moon. Iodine is not.",
+		},
+		{
+			text:  "Ant Ant, ant-like",
+			title: "Ant",
+			want:  "_____ _____, _____-like",
+		},
+		{
+			text:  "Mercury is a planet, Mercury (planet) is closest to the Sun.",
+			title: "Mercury (planet)",
+			want:  "_____ is a planet, _____ is closest to the Sun.",
+		},
+		{
+			text:  "Zürich liegt am Zürichsee, Zürich ist groß.",
+			title: "Zürich",
+			want:  "_____ liegt am Zürichsee, _____ ist groß.",
+		},
+		{
+			text:  "Art and artists",
+			title: "art",
+			want:  "_____ and artists",
+		},
+	}
+	for _, tt := range tests {
+		if got := redactTitle(tt.text, tt.title); got != tt.want {
+			t.Errorf("redactTitle(%q, %q) = %q, want %q", tt.text, tt.title, got, tt.want)
+		}
+	}
+}
+
+func TestQuizCategories(t *testing.T) {
+	tests := []struct {
+		categories []string
+		answer     string
+		want       []string
+	}{
+		{
+			categories: []string{"Participants in the war", "Ant genera", "Insects"},
+			answer:     "Ant",
+			want:       []string{"Participants in the war", "Insects"},
+		},
+		{
+			categories: []string{"Films directed by Stanley Kubrick", "1968 films"},
+			answer:     "Stanley Kubrick",
+			want:       []string{"1968 films"},
+		},
+	}
+	for _, tt := range tests {
+		if got := quizCategories(tt.categories, tt.answer); !slices.Equal(got, tt.want) {
+			t.Errorf("quizCategories(%q, %q) = %q, want %q", tt.categories, tt.answer, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,96 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/rand"
+
+	"go.uber.org/zap"
+
+	"knowledgeleaf/app"
+	"knowledgeleaf/externalapi/wikipedia"
+)
+
+// ErrNoLinkedArticle is returned when none of the links of a page leads to a known article.
+var ErrNoLinkedArticle = errors.New("no linked article found")
+
+const (
+	// maxBreadcrumbLength bounds the path of titles walked so far.
+	maxBreadcrumbLength = 50
+	// maxLinkChecks bounds the store lookups made while looking for a known linked title.
+	maxLinkChecks = 100
+)
+
+// nextArticle selects a random article linked from the given page, among the titles of the store
+// of the language. Titles already part of the breadcrumb path are not selected again.
+func nextArticle(
+	ctx context.Context,
+	triviaBackend *RandomTriviaBackend,
+	lang string,
+	from string,
+	path []string,
+) (WikiSummary, error) {
+	titles, err := triviaBackend.titles(lang)
+	if err != nil {
+		return WikiSummary{}, err
+	}
+	links, err := wikipedia.NewLanguageClient(triviaBackend.language(lang)).Links(ctx, from)
+	if err != nil {
+		return WikiSummary{}, err
+	}
+	visited := make(map[string]struct{}, len(path)+1)
+	for _, title := range append(path, from) {
+		visited[titleKey(title)] = struct{}{}
+	}
+	rand.Shuffle(len(links), func(i, j int) {
+		links[i], links[j] = links[j], links[i]
+	})
+
+	logger := app.LoggerFromContext(ctx)
+	var checks, fetches int
+	for _, link := range links {
+		if _, ok := visited[titleKey(link)]; ok {
+			continue
+		}
+		if checks == maxLinkChecks || fetches == maxTries {
+			break
+		}
+		checks++
+		exists, err := titles.Exists(ctx, seenKey(link))
+		if err != nil {
+			return WikiSummary{}, err
+		}
+		if !exists {
+			continue
+		}
+
+		fetches++
+		summary, err := fetchArticle(ctx, triviaBackend, lang, link)
+		var expanded bool
+		if err == nil {
+			expanded = summary.Type == articleTypeDisambiguation
+			summary, err = applyPagePolicy(ctx, triviaBackend, TitleQuery{Lang: lang}, link, summary)
+		}
+		if err == nil {
+			if reason := rejectArticle(triviaBackend.filters, summary); reason != "" {
+				// An expanded disambiguation page was not filtered itself, only the candidate it was replaced with
+				if !expanded {
+					if err := triviaBackend.Reject(ctx, lang, seenKey(link), reason); err != nil {
+						logger.Warn("recording rejected title failed", zap.Error(err), zap.String("title", link))
+					}
+				}
+				err = fmt.Errorf("%w: %s", errSkippedPage, reason)
+			}
+		}
+		switch {
+		case err == nil:
+			return summary, nil
+		case errors.Is(err, wikipedia.ErrNotFound), errors.Is(err, errSkippedPage):
+			logger.Info("linked page skipped - retrying", zap.String("title", link), zap.Error(err))
+		default:
+			return WikiSummary{}, &ArticleError{Title: link, Err: err}
+		}
+	}
+	return WikiSummary{}, ErrNoLinkedArticle
+}
@@ -0,0 +1,63 @@
+package repository
+
+import (
+	"context"
+	"errors"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+
+	"knowledgeleaf/database"
+)
+
+// ArticleRepository stores fetched article summaries, keyed by the language and the title used for the lookup.
+type ArticleRepository interface {
+	FindArticle(ctx context.Context, lang string, title string) (database.Article, error)
+	SaveArticle(ctx context.Context, article *database.Article) error
+}
+
+type postgresArticleRepository struct {
+	db *gorm.DB
+}
+
+func (p postgresArticleRepository) FindArticle(ctx context.Context, lang string, title string) (database.Article, error) {
+	var article database.Article
+	err := p.db.WithContext(ctx).First(&article, "lang = ? AND title = ?", lang, title).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return database.Article{}, ErrNotFound
+	}
+	return article, err
+}
+
+func (p postgresArticleRepository) SaveArticle(ctx context.Context, article *database.Article) error {
+	return p.db.WithContext(ctx).
+		Clauses(clause.OnConflict{
+			Columns:   []clause.Column{{Name: "lang"}, {Name: "title"}},
+			DoUpdates: clause.AssignmentColumns(articleUpdateColumns),
+		}).
+		Create(article).Error
+}
+
+var articleUpdateColumns = []string{
+	"display_title",
+	"type",
+	"page_id",
+	"wikibase_item",
+	"extract",
+	"description",
+	"url",
+	"thumbnail_url",
+	"thumbnail_width",
+	"thumbnail_height",
+	"original_image_url",
+	"original_image_width",
+	"original_image_height",
+	"categories",
+	"revision",
+	"fetched_at",
+	"updated_at",
+}
+
+func NewPostgresArticleRepository(db *gorm.DB) ArticleRepository {
+	return postgresArticleRepository{db: db}
+}
@@ -0,0 +1,137 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"math/rand"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+
+	"knowledgeleaf/database"
+)
+
+// CategoryRepository stores the Wikipedia categories each title belongs to,
+// so that random selection can be restricted to a set of categories.
+// Categories are tracked for the titles of a single language.
+type CategoryRepository interface {
+	AddTitleCategories(ctx context.Context, title string, categories []string) error
+	// AddCategoryMembers stores the titles as members of the category, and records the load time.
+	AddCategoryMembers(ctx context.Context, category string, titles []string) error
+	// MembersLoadedAt returns when the members of the category were last stored, or ErrNotFound.
+	MembersLoadedAt(ctx context.Context, category string) (time.Time, error)
+	RandomTitle(ctx context.Context, categories []string) (string, error)
+	// TitleAt returns the title at position n, modulo the number of matching titles, in title order.
+	TitleAt(ctx context.Context, categories []string, n uint64) (string, error)
+}
+
+// categoryMembersBatchSize bounds the rows of each insert of category members.
+const categoryMembersBatchSize = 1000
+
+type postgresCategoryRepository struct {
+	db   *gorm.DB
+	lang string
+}
+
+func (p postgresCategoryRepository) AddTitleCategories(ctx context.Context, title string, categories []string) error {
+	if len(categories) == 0 {
+		return nil
+	}
+	rows := make([]*database.WikipediaTitleCategory, 0, len(categories))
+	for _, category := range categories {
+		rows = append(rows, &database.WikipediaTitleCategory{
+			Title:    title,
+			Category: category,
+		})
+	}
+	return p.db.WithContext(ctx).
+		Clauses(clause.OnConflict{DoNothing: true}).
+		Create(rows).Error
+}
+
+func (p postgresCategoryRepository) AddCategoryMembers(ctx context.Context, category string, titles []string) error {
+	rows := make([]*database.WikipediaTitleCategory, 0, len(titles))
+	for _, title := range titles {
+		rows = append(rows, &database.WikipediaTitleCategory{
+			Title:    title,
+			Category: category,
+		})
+	}
+	return p.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if len(rows) > 0 {
+			err := tx.Clauses(clause.OnConflict{DoNothing: true}).
+				CreateInBatches(rows, categoryMembersBatchSize).Error
+			if err != nil {
+				return err
+			}
+		}
+		return tx.Clauses(clause.OnConflict{UpdateAll: true}).Create(&database.WikipediaCategory{
+			Category:        category,
+			MembersLoadedAt: time.Now(),
+		}).Error
+	})
+}
+
+func (p postgresCategoryRepository) MembersLoadedAt(ctx context.Context, category string) (time.Time, error) {
+	var row database.WikipediaCategory
+	err := p.db.WithContext(ctx).Where("category = ?", category).Take(&row).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return time.Time{}, ErrNotFound
+	}
+	return row.MembersLoadedAt, err
+}
+
+// RandomTitle returns a random title that belongs to at least one of the given categories.
+// Titles matching multiple categories are not favored over the rest.
+func (p postgresCategoryRepository) RandomTitle(ctx context.Context, categories []string) (string, error) {
+	count, err := p.count(ctx, categories)
+	if err != nil {
+		return "", err
+	}
+	if count == 0 {
+		return "", ErrNotFound
+	}
+	return p.titleAtOffset(ctx, categories, rand.Int63n(count))
+}
+
+func (p postgresCategoryRepository) TitleAt(ctx context.Context, categories []string, n uint64) (string, error) {
+	count, err := p.count(ctx, categories)
+	if err != nil {
+		return "", err
+	}
+	if count == 0 {
+		return "", ErrNotFound
+	}
+	return p.titleAtOffset(ctx, categories, int64(n%uint64(count)))
+}
+
+// count returns the number of titles, not rejected, belonging to at least one of the categories.
+func (p postgresCategoryRepository) count(ctx context.Context, categories []string) (int64, error) {
+	var count int64
+	err := p.db.WithContext(ctx).Raw(
+		`SELECT COUNT(DISTINCT title) FROM wikipedia_title_categories c WHERE category IN ?
+		AND NOT EXISTS (SELECT 1 FROM rejected_titles r WHERE r.lang = ? AND r.title = c.title)`,
+		categories, p.lang).Scan(&count).Error
+	return count, err
+}
+
+// titleAtOffset returns the title at the offset among the titles counted by count, in title order.
+func (p postgresCategoryRepository) titleAtOffset(ctx context.Context, categories []string, offset int64) (string, error) {
+	var titles []string
+	err := p.db.WithContext(ctx).Raw(
+		`SELECT DISTINCT title FROM wikipedia_title_categories c WHERE category IN ?
+		AND NOT EXISTS (SELECT 1 FROM rejected_titles r WHERE r.lang = ? AND r.title = c.title)
+		ORDER BY title OFFSET ? LIMIT 1`, categories, p.lang, offset).Scan(&titles).Error
+	if err != nil {
+		return "", err
+	}
+	if len(titles) == 0 {
+		return "", ErrNotFound
+	}
+	return titles[0], nil
+}
+
+func NewPostgresCategoryRepository(db *gorm.DB, lang string) CategoryRepository {
+	return postgresCategoryRepository{db: db, lang: lang}
+}
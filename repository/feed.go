@@ -0,0 +1,86 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// FeedCache keeps encoded Wikipedia feed responses until a given expiry time.
+// Get returns ErrNotFound for missing and expired entries.
+type FeedCache interface {
+	Get(ctx context.Context, key string) ([]byte, error)
+	Set(ctx context.Context, key string, value []byte, expiresAt time.Time) error
+}
+
+type memoryFeedEntry struct {
+	value     []byte
+	expiresAt time.Time
+}
+
+type memoryFeedCache struct {
+	mu      sync.Mutex
+	entries map[string]memoryFeedEntry
+}
+
+func (m *memoryFeedCache) Get(_ context.Context, key string) ([]byte, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	entry, ok := m.entries[key]
+	if !ok || !time.Now().Before(entry.expiresAt) {
+		return nil, ErrNotFound
+	}
+	return entry.value, nil
+}
+
+func (m *memoryFeedCache) Set(_ context.Context, key string, value []byte, expiresAt time.Time) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	now := time.Now()
+	for k, entry := range m.entries {
+		if !now.Before(entry.expiresAt) {
+			delete(m.entries, k)
+		}
+	}
+	m.entries[key] = memoryFeedEntry{value: value, expiresAt: expiresAt}
+	return nil
+}
+
+// NewMemoryFeedCache keeps the feed responses in process memory.
+func NewMemoryFeedCache() FeedCache {
+	return &memoryFeedCache{entries: make(map[string]memoryFeedEntry)}
+}
+
+type redisFeedCache struct {
+	client *redis.Client
+}
+
+func (r redisFeedCache) Get(ctx context.Context, key string) ([]byte, error) {
+	value, err := r.client.Get(ctx, redisFeedKey(key)).Bytes()
+	if errors.Is(err, redis.Nil) {
+		return nil, ErrNotFound
+	}
+	return value, err
+}
+
+func (r redisFeedCache) Set(ctx context.Context, key string, value []byte, expiresAt time.Time) error {
+	ttl := time.Until(expiresAt)
+	if ttl <= 0 {
+		// A zero expiration would keep the entry forever
+		return nil
+	}
+	return r.client.Set(ctx, redisFeedKey(key), value, ttl).Err()
+}
+
+// NewRedisFeedCache shares the feed responses across application instances.
+func NewRedisFeedCache(client *redis.Client) FeedCache {
+	return redisFeedCache{client: client}
+}
+
+// redisFeedKey returns the Redis key of a feed cache entry.
+func redisFeedKey(key string) string {
+	return "feed:" + key
+}
@@ -0,0 +1,182 @@
+package repository
+
+import (
+	"cmp"
+	"context"
+	"math/rand"
+	"slices"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// memoryTitleStore keeps the titles in process memory. Removing a title moves the last title
+// into its position, so that random selection remains a single slice access.
+type memoryTitleStore struct {
+	mu        sync.RWMutex
+	titles    []string
+	positions map[string]int
+	staged    []string
+	// prefixIndex holds the titles sorted by their lowercase form, rebuilt on the first search after a change
+	prefixIndex      []indexedTitle
+	prefixIndexStale bool
+}
+
+type indexedTitle struct {
+	key   string
+	title string
+}
+
+func (m *memoryTitleStore) Random(context.Context) (string, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	if len(m.titles) == 0 {
+		return "", ErrNotFound
+	}
+	return m.titles[rand.Intn(len(m.titles))], nil
+}
+
+func (m *memoryTitleStore) TitleAt(_ context.Context, n uint64) (string, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	if len(m.titles) == 0 {
+		return "", ErrNotFound
+	}
+	return m.titles[n%uint64(len(m.titles))], nil
+}
+
+func (m *memoryTitleStore) Count(context.Context) (int64, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return int64(len(m.titles)), nil
+}
+
+func (m *memoryTitleStore) Exists(_ context.Context, title string) (bool, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	_, ok := m.positions[title]
+	return ok, nil
+}
+
+func (m *memoryTitleStore) Add(_ context.Context, titles ...string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.add(titles)
+	return nil
+}
+
+func (m *memoryTitleStore) add(titles []string) {
+	m.prefixIndexStale = true
+	for _, title := range titles {
+		if _, ok := m.positions[title]; ok {
+			continue
+		}
+		m.positions[title] = len(m.titles)
+		m.titles = append(m.titles, title)
+	}
+}
+
+func (m *memoryTitleStore) Remove(_ context.Context, titles ...string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for _, title := range titles {
+		i, ok := m.positions[title]
+		if !ok {
+			continue
+		}
+		last := len(m.titles) - 1
+		m.titles[i] = m.titles[last]
+		m.positions[m.titles[i]] = i
+		m.titles = m.titles[:last]
+		delete(m.positions, title)
+		m.prefixIndexStale = true
+	}
+	return nil
+}
+
+// Iterate walks a snapshot of the titles, so that fn may modify the store.
+func (m *memoryTitleStore) Iterate(_ context.Context, fn func(title string) bool) error {
+	m.mu.RLock()
+	titles := append([]string(nil), m.titles...)
+	m.mu.RUnlock()
+	for _, title := range titles {
+		if !fn(title) {
+			return nil
+		}
+	}
+	return nil
+}
+
+func (m *memoryTitleStore) Stage(_ context.Context, titles []string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.staged = append(m.staged, titles...)
+	return nil
+}
+
+func (m *memoryTitleStore) Commit(context.Context) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.titles = nil
+	m.positions = make(map[string]int, len(m.staged))
+	m.add(m.staged)
+	m.staged = nil
+	return nil
+}
+
+// Search binary searches the prefix index for the prefix matches, then scans it for the titles
+// containing the query.
+func (m *memoryTitleStore) Search(_ context.Context, query string, limit int, offset int) ([]string, error) {
+	index := m.searchIndex()
+	key := strings.ToLower(query)
+	from := sort.Search(len(index), func(i int) bool { return index[i].key >= key })
+	var matches []string
+	for i := from; i < len(index) && len(matches) < offset+limit && strings.HasPrefix(index[i].key, key); i++ {
+		matches = append(matches, index[i].title)
+	}
+	for _, t := range index {
+		if len(matches) >= offset+limit {
+			break
+		}
+		if strings.Contains(t.key, key) && !strings.HasPrefix(t.key, key) {
+			matches = append(matches, t.title)
+		}
+	}
+	if offset >= len(matches) {
+		return nil, nil
+	}
+	return matches[offset:min(offset+limit, len(matches))], nil
+}
+
+func (m *memoryTitleStore) searchIndex() []indexedTitle {
+	m.mu.RLock()
+	if !m.prefixIndexStale {
+		defer m.mu.RUnlock()
+		return m.prefixIndex
+	}
+	m.mu.RUnlock()
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.prefixIndexStale {
+		index := make([]indexedTitle, 0, len(m.titles))
+		for _, title := range m.titles {
+			index = append(index, indexedTitle{key: strings.ToLower(title), title: title})
+		}
+		slices.SortFunc(index, func(a, b indexedTitle) int {
+			return cmp.Or(cmp.Compare(a.key, b.key), cmp.Compare(a.title, b.title))
+		})
+		// The index is replaced rather than updated, so that returned indexes are never modified
+		m.prefixIndex = index
+		m.prefixIndexStale = false
+	}
+	return m.prefixIndex
+}
+
+// NewMemoryTitleStore stores titles in process memory, duplicates are ignored.
+// Titles are not persisted, neither are removals.
+func NewMemoryTitleStore(titles []string) TitleStore {
+	m := &memoryTitleStore{positions: make(map[string]int, len(titles))}
+	m.add(titles)
+	return m
+}
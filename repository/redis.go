@@ -0,0 +1,236 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"slices"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// Redis keys shared between the loader and the title selection, for the English Wikipedia.
+// Other languages use the same keys with the language code after the "datasource:wikipedia" prefix.
+const (
+	// RedisKeyTitles is the set titles are randomly selected from.
+	RedisKeyTitles = "datasource:wikipedia"
+	// RedisKeyTitlesStaging is the set the loader fills, before it replaces RedisKeyTitles.
+	RedisKeyTitlesStaging = "datasource:wikipedia:staging"
+	// RedisKeyRejectedTitles holds the titles excluded from selection.
+	RedisKeyRejectedTitles = "datasource:wikipedia:rejected"
+	// RedisKeyTitlesIndex is a sorted set of the titles of RedisKeyTitles, all scored 0, so that
+	// titles are ranked lexicographically and can be looked up by position.
+	RedisKeyTitlesIndex = "datasource:wikipedia:index"
+)
+
+// redisTitleKeys returns the titles, staging, rejected titles and index keys of a language.
+func redisTitleKeys(lang string) (string, string, string, string) {
+	if lang == "en" {
+		return RedisKeyTitles, RedisKeyTitlesStaging, RedisKeyRejectedTitles, RedisKeyTitlesIndex
+	}
+	prefix := RedisKeyTitles + ":" + lang
+	return prefix, prefix + ":staging", prefix + ":rejected", prefix + ":index"
+}
+
+// redisSAddBatchSize bounds the members of each SADD command sent in a pipeline.
+const redisSAddBatchSize = 250
+
+type redisTitleStore struct {
+	client      *redis.Client
+	titlesKey   string
+	stagingKey  string
+	rejectedKey string
+	indexKey    string
+	// stagingReset is set once leftovers of interrupted loads have been cleared
+	stagingReset bool
+}
+
+func (r *redisTitleStore) Random(ctx context.Context) (string, error) {
+	title, err := r.client.SRandMember(ctx, r.titlesKey).Result()
+	if errors.Is(err, redis.Nil) {
+		return "", ErrNotFound
+	}
+	return title, err
+}
+
+// TitleAt looks the title up by its rank in the index, which gives each title a stable position.
+// The index is built from the set when missing, such as for titles loaded before it existed.
+func (r *redisTitleStore) TitleAt(ctx context.Context, n uint64) (string, error) {
+	count, err := r.client.ZCard(ctx, r.indexKey).Result()
+	if err != nil {
+		return "", err
+	}
+	if count == 0 {
+		if count, err = r.buildIndex(ctx); err != nil {
+			return "", err
+		}
+	}
+	if count == 0 {
+		return "", ErrNotFound
+	}
+	offset := int64(n % uint64(count))
+	titles, err := r.client.ZRange(ctx, r.indexKey, offset, offset).Result()
+	if err != nil {
+		return "", err
+	}
+	if len(titles) == 0 {
+		return "", ErrNotFound
+	}
+	return titles[0], nil
+}
+
+// buildIndex replaces the index with the members of the titles set, and returns its size.
+func (r *redisTitleStore) buildIndex(ctx context.Context) (int64, error) {
+	return r.client.ZUnionStore(ctx, r.indexKey, &redis.ZStore{
+		Keys:    []string{r.titlesKey},
+		Weights: []float64{0},
+	}).Result()
+}
+
+func (r *redisTitleStore) Count(ctx context.Context) (int64, error) {
+	return r.client.SCard(ctx, r.titlesKey).Result()
+}
+
+func (r *redisTitleStore) Exists(ctx context.Context, title string) (bool, error) {
+	return r.client.SIsMember(ctx, r.titlesKey, title).Result()
+}
+
+// redisAddTitlesScript adds the titles to the set, and to the index only when it exists: a missing index
+// is rebuilt from the whole set by TitleAt, while creating it here would leave out the titles already loaded.
+var redisAddTitlesScript = redis.NewScript(`
+redis.call('SADD', KEYS[1], unpack(ARGV))
+if redis.call('EXISTS', KEYS[2]) == 1 then
+	for _, title in ipairs(ARGV) do
+		redis.call('ZADD', KEYS[2], 0, title)
+	end
+end
+return 0
+`)
+
+func (r *redisTitleStore) Add(ctx context.Context, titles ...string) error {
+	for batch := range slices.Chunk(titles, redisSAddBatchSize) {
+		err := redisAddTitlesScript.Run(ctx, r.client, []string{r.titlesKey, r.indexKey}, toMembers(batch)...).Err()
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (r *redisTitleStore) Remove(ctx context.Context, titles ...string) error {
+	if len(titles) == 0 {
+		return nil
+	}
+	_, err := r.client.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		pipe.SRem(ctx, r.titlesKey, toMembers(titles)...)
+		pipe.ZRem(ctx, r.indexKey, toMembers(titles)...)
+		return nil
+	})
+	return err
+}
+
+// Iterate scans the set, titles added or removed meanwhile may or may not be visited.
+func (r *redisTitleStore) Iterate(ctx context.Context, fn func(title string) bool) error {
+	iter := r.client.SScan(ctx, r.titlesKey, 0, "", iterateBatchSize).Iterator()
+	for iter.Next(ctx) {
+		if !fn(iter.Val()) {
+			return nil
+		}
+	}
+	return iter.Err()
+}
+
+// Stage adds the titles to the staging set, they become available for selection on Commit.
+func (r *redisTitleStore) Stage(ctx context.Context, titles []string) error {
+	if !r.stagingReset {
+		if err := r.client.Del(ctx, r.stagingKey).Err(); err != nil {
+			return err
+		}
+		r.stagingReset = true
+	}
+	return r.add(ctx, r.stagingKey, titles)
+}
+
+// Commit atomically replaces the selectable titles with the staged ones, minus the rejected titles,
+// and rebuilds the index out of them.
+func (r *redisTitleStore) Commit(ctx context.Context) error {
+	n, err := r.client.Exists(ctx, r.stagingKey).Result()
+	if err != nil || n == 0 {
+		return err
+	}
+	_, err = r.client.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		pipe.SDiffStore(ctx, r.stagingKey, r.stagingKey, r.rejectedKey)
+		pipe.Rename(ctx, r.stagingKey, r.titlesKey)
+		pipe.ZUnionStore(ctx, r.indexKey, &redis.ZStore{Keys: []string{r.titlesKey}, Weights: []float64{0}})
+		return nil
+	})
+	return err
+}
+
+func (r *redisTitleStore) Reject(ctx context.Context, title string, _ string) error {
+	_, err := r.client.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		pipe.SRem(ctx, r.titlesKey, title)
+		pipe.ZRem(ctx, r.indexKey, title)
+		pipe.SAdd(ctx, r.rejectedKey, title)
+		return nil
+	})
+	return err
+}
+
+func (r *redisTitleStore) NotifyLoaded(ctx context.Context) error {
+	return r.client.Publish(ctx, TitlesLoadedChannel, "").Err()
+}
+
+func (r *redisTitleStore) WatchLoaded(ctx context.Context, fn func()) error {
+	pubsub := r.client.Subscribe(ctx, TitlesLoadedChannel)
+	defer pubsub.Close()
+	// Wait for the subscription confirmation, so that failures are reported
+	if _, err := pubsub.Receive(ctx); err != nil {
+		return err
+	}
+	messages := pubsub.Channel()
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case _, ok := <-messages:
+			if !ok {
+				return errors.New("redis subscription closed")
+			}
+			fn()
+		}
+	}
+}
+
+func (r *redisTitleStore) add(ctx context.Context, key string, titles []string) error {
+	if len(titles) == 0 {
+		return nil
+	}
+	_, err := r.client.Pipelined(ctx, func(pipe redis.Pipeliner) error {
+		for batch := range slices.Chunk(toMembers(titles), redisSAddBatchSize) {
+			pipe.SAdd(ctx, key, batch...)
+		}
+		return nil
+	})
+	return err
+}
+
+func toMembers(titles []string) []any {
+	members := make([]any, 0, len(titles))
+	for _, title := range titles {
+		members = append(members, title)
+	}
+	return members
+}
+
+// NewRedisTitleStore stores the titles of the Wikipedia edition in the given language in a Redis set.
+// Page views are not supported.
+func NewRedisTitleStore(client *redis.Client, lang string) TitleStore {
+	titlesKey, stagingKey, rejectedKey, indexKey := redisTitleKeys(lang)
+	return &redisTitleStore{
+		client:      client,
+		titlesKey:   titlesKey,
+		stagingKey:  stagingKey,
+		rejectedKey: rejectedKey,
+		indexKey:    indexKey,
+	}
+}
@@ -0,0 +1,36 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/redis/go-redis/v9"
+)
+
+func TestRedisTitleStoreAddKeepsIndexComplete(t *testing.T) {
+	ctx := context.Background()
+	client := redis.NewClient(&redis.Options{Addr: miniredis.RunT(t).Addr()})
+	store := NewRedisTitleStore(client, "en")
+
+	// Titles loaded before the index existed
+	var titles []string
+	for i := range 20 {
+		titles = append(titles, fmt.Sprintf("Loaded_%02d", i))
+	}
+	if err := client.SAdd(ctx, RedisKeyTitles, toMembers(titles)...).Err(); err != nil {
+		t.Fatal(err)
+	}
+	if err := store.Add(ctx, "Added_1", "Added_2"); err != nil {
+		t.Fatal(err)
+	}
+	titles = append(titles, "Added_1", "Added_2")
+	assertDenseTitles(t, store, titles)
+
+	// Titles added once the index exists are indexed too
+	if err := store.Add(ctx, "Added_3"); err != nil {
+		t.Fatal(err)
+	}
+	assertDenseTitles(t, store, append(titles, "Added_3"))
+}
@@ -0,0 +1,240 @@
+package repository
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+
+	"knowledgeleaf/database"
+)
+
+// SessionRepository tracks the titles served to each client session.
+// Entries expire after the configured time to live.
+type SessionRepository interface {
+	// MarkSeen records a title as seen by the session, reporting whether it was not seen yet.
+	// Concurrent calls for the same title report it as newly seen at most once.
+	MarkSeen(ctx context.Context, session string, title string) (bool, error)
+	// Forget removes a title from the titles seen by the session, such as after its article failed to load.
+	Forget(ctx context.Context, session string, title string) error
+	SeenCount(ctx context.Context, session string) (int64, error)
+	SeenTitles(ctx context.Context, session string) ([]string, error)
+	Reset(ctx context.Context, session string) error
+}
+
+// PurgingSessionRepository is implemented by session repositories whose expired entries
+// are not removed on their own.
+type PurgingSessionRepository interface {
+	// PurgeExpired deletes the expired entries, and returns their number.
+	PurgeExpired(ctx context.Context) (int64, error)
+}
+
+type postgresSessionRepository struct {
+	db  *gorm.DB
+	ttl time.Duration
+}
+
+func (p postgresSessionRepository) MarkSeen(ctx context.Context, session string, title string) (bool, error) {
+	// Expired entries are renewed, entries still alive are left untouched and report no row
+	result := p.db.WithContext(ctx).
+		Clauses(clause.OnConflict{
+			Columns:   []clause.Column{{Name: "session_id"}, {Name: "title"}},
+			DoUpdates: clause.Assignments(map[string]any{"created_at": time.Now()}),
+			Where: clause.Where{Exprs: []clause.Expression{
+				clause.Expr{SQL: "session_seen_titles.created_at <= ?", Vars: []any{p.expiredBefore()}},
+			}},
+		}).
+		Create(&database.SessionSeenTitle{SessionID: session, Title: title})
+	return result.RowsAffected > 0, result.Error
+}
+
+func (p postgresSessionRepository) Forget(ctx context.Context, session string, title string) error {
+	return p.db.WithContext(ctx).
+		Where("session_id = ? AND title = ?", session, title).
+		Delete(&database.SessionSeenTitle{}).Error
+}
+
+func (p postgresSessionRepository) SeenCount(ctx context.Context, session string) (int64, error) {
+	var n int64
+	err := p.db.WithContext(ctx).Model(&database.SessionSeenTitle{}).
+		Where("session_id = ? AND created_at > ?", session, p.expiredBefore()).
+		Count(&n).Error
+	return n, err
+}
+
+func (p postgresSessionRepository) SeenTitles(ctx context.Context, session string) ([]string, error) {
+	var titles []string
+	err := p.db.WithContext(ctx).Model(&database.SessionSeenTitle{}).
+		Where("session_id = ? AND created_at > ?", session, p.expiredBefore()).
+		Pluck("title", &titles).Error
+	return titles, err
+}
+
+func (p postgresSessionRepository) Reset(ctx context.Context, session string) error {
+	return p.db.WithContext(ctx).
+		Where("session_id = ?", session).
+		Delete(&database.SessionSeenTitle{}).Error
+}
+
+func (p postgresSessionRepository) PurgeExpired(ctx context.Context) (int64, error) {
+	result := p.db.WithContext(ctx).
+		Where("created_at <= ?", p.expiredBefore()).
+		Delete(&database.SessionSeenTitle{})
+	return result.RowsAffected, result.Error
+}
+
+func (p postgresSessionRepository) expiredBefore() time.Time {
+	return time.Now().Add(-p.ttl)
+}
+
+// NewPostgresSessionRepository keeps the seen titles in Postgres. Entries expire individually.
+func NewPostgresSessionRepository(db *gorm.DB, ttl time.Duration) SessionRepository {
+	return postgresSessionRepository{db: db, ttl: ttl}
+}
+
+type redisSessionRepository struct {
+	client *redis.Client
+	ttl    time.Duration
+}
+
+func (r redisSessionRepository) MarkSeen(ctx context.Context, session string, title string) (bool, error) {
+	key := RedisKeySessionSeen(session)
+	var added *redis.IntCmd
+	_, err := r.client.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		added = pipe.SAdd(ctx, key, title)
+		pipe.Expire(ctx, key, r.ttl)
+		return nil
+	})
+	if err != nil {
+		return false, err
+	}
+	return added.Val() > 0, nil
+}
+
+func (r redisSessionRepository) Forget(ctx context.Context, session string, title string) error {
+	return r.client.SRem(ctx, RedisKeySessionSeen(session), title).Err()
+}
+
+func (r redisSessionRepository) SeenCount(ctx context.Context, session string) (int64, error) {
+	return r.client.SCard(ctx, RedisKeySessionSeen(session)).Result()
+}
+
+func (r redisSessionRepository) SeenTitles(ctx context.Context, session string) ([]string, error) {
+	return r.client.SMembers(ctx, RedisKeySessionSeen(session)).Result()
+}
+
+func (r redisSessionRepository) Reset(ctx context.Context, session string) error {
+	return r.client.Del(ctx, RedisKeySessionSeen(session)).Err()
+}
+
+// NewRedisSessionRepository keeps the seen titles of each session in a Redis set,
+// which expires once the session has been inactive for the time to live.
+func NewRedisSessionRepository(client *redis.Client, ttl time.Duration) SessionRepository {
+	return redisSessionRepository{client: client, ttl: ttl}
+}
+
+func RedisKeySessionSeen(session string) string {
+	return "session:" + session + ":seen"
+}
+
+type memorySession struct {
+	titles    map[string]struct{}
+	expiresAt time.Time
+}
+
+// memorySessionSweepInterval is the number of claims between two sweeps of the expired sessions.
+const memorySessionSweepInterval = 1000
+
+type memorySessionRepository struct {
+	mu       sync.Mutex
+	sessions map[string]*memorySession
+	ttl      time.Duration
+	// claims counts the claims since the last sweep of the expired sessions
+	claims int
+}
+
+func (m *memorySessionRepository) MarkSeen(_ context.Context, session string, title string) (bool, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	now := time.Now()
+	if m.claims++; m.claims >= memorySessionSweepInterval {
+		// Sessions that are never looked up again would be kept forever otherwise
+		m.claims = 0
+		for id, s := range m.sessions {
+			if now.After(s.expiresAt) {
+				delete(m.sessions, id)
+			}
+		}
+	}
+	s := m.session(session)
+	if s == nil {
+		s = &memorySession{titles: make(map[string]struct{})}
+		m.sessions[session] = s
+	}
+	_, seen := s.titles[title]
+	s.titles[title] = struct{}{}
+	s.expiresAt = now.Add(m.ttl)
+	return !seen, nil
+}
+
+func (m *memorySessionRepository) Forget(_ context.Context, session string, title string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if s := m.session(session); s != nil {
+		delete(s.titles, title)
+	}
+	return nil
+}
+
+func (m *memorySessionRepository) SeenCount(_ context.Context, session string) (int64, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	s := m.session(session)
+	if s == nil {
+		return 0, nil
+	}
+	return int64(len(s.titles)), nil
+}
+
+func (m *memorySessionRepository) SeenTitles(_ context.Context, session string) ([]string, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	s := m.session(session)
+	if s == nil {
+		return nil, nil
+	}
+	titles := make([]string, 0, len(s.titles))
+	for title := range s.titles {
+		titles = append(titles, title)
+	}
+	return titles, nil
+}
+
+func (m *memorySessionRepository) Reset(_ context.Context, session string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	delete(m.sessions, session)
+	return nil
+}
+
+// session returns the session when it has not expired, and evicts it otherwise.
+func (m *memorySessionRepository) session(session string) *memorySession {
+	s, ok := m.sessions[session]
+	if !ok {
+		return nil
+	}
+	if time.Now().After(s.expiresAt) {
+		delete(m.sessions, session)
+		return nil
+	}
+	return s
+}
+
+// NewMemorySessionRepository keeps the seen titles in process memory,
+// for deployments without a database.
+func NewMemorySessionRepository(ttl time.Duration) SessionRepository {
+	return &memorySessionRepository{sessions: make(map[string]*memorySession), ttl: ttl}
+}
@@ -0,0 +1,96 @@
+package repository
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/redis/go-redis/v9"
+)
+
+func TestSessionRepositoryClaims(t *testing.T) {
+	repositories := map[string]func(t *testing.T) SessionRepository{
+		"memory": func(*testing.T) SessionRepository {
+			return NewMemorySessionRepository(time.Hour)
+		},
+		"redis": func(t *testing.T) SessionRepository {
+			client := redis.NewClient(&redis.Options{Addr: miniredis.RunT(t).Addr()})
+			return NewRedisSessionRepository(client, time.Hour)
+		},
+	}
+	for name, newRepository := range repositories {
+		t.Run(name, func(t *testing.T) {
+			ctx := context.Background()
+			sessions := newRepository(t)
+
+			claimed, err := sessions.MarkSeen(ctx, "s1", "Title")
+			if err != nil || !claimed {
+				t.Fatalf("first MarkSeen = %v, %v, want claimed", claimed, err)
+			}
+			claimed, err = sessions.MarkSeen(ctx, "s1", "Title")
+			if err != nil || claimed {
+				t.Fatalf("second MarkSeen = %v, %v, want already seen", claimed, err)
+			}
+			claimed, err = sessions.MarkSeen(ctx, "s2", "Title")
+			if err != nil || !claimed {
+				t.Fatalf("MarkSeen of another session = %v, %v, want claimed", claimed, err)
+			}
+
+			if err := sessions.Forget(ctx, "s1", "Title"); err != nil {
+				t.Fatal(err)
+			}
+			claimed, err = sessions.MarkSeen(ctx, "s1", "Title")
+			if err != nil || !claimed {
+				t.Fatalf("MarkSeen after Forget = %v, %v, want claimed", claimed, err)
+			}
+
+			// Concurrent claims of the same title succeed once
+			var (
+				wg    sync.WaitGroup
+				wins  atomic.Int32
+				fails atomic.Int32
+			)
+			for range 20 {
+				wg.Add(1)
+				go func() {
+					defer wg.Done()
+					claimed, err := sessions.MarkSeen(ctx, "s3", "Contended")
+					switch {
+					case err != nil:
+						fails.Add(1)
+					case claimed:
+						wins.Add(1)
+					}
+				}()
+			}
+			wg.Wait()
+			if fails.Load() > 0 || wins.Load() != 1 {
+				t.Fatalf("concurrent claims: %d succeeded, %d failed, want 1 success", wins.Load(), fails.Load())
+			}
+
+			n, err := sessions.SeenCount(ctx, "s1")
+			if err != nil || n != 1 {
+				t.Fatalf("SeenCount = %d, %v, want 1", n, err)
+			}
+		})
+	}
+}
+
+func TestMemorySessionRepositoryExpiry(t *testing.T) {
+	ctx := context.Background()
+	sessions := NewMemorySessionRepository(time.Millisecond)
+	if _, err := sessions.MarkSeen(ctx, "s1", "Title"); err != nil {
+		t.Fatal(err)
+	}
+	time.Sleep(5 * time.Millisecond)
+	claimed, err := sessions.MarkSeen(ctx, "s1", "Title")
+	if err != nil || !claimed {
+		t.Fatalf("MarkSeen of an expired session = %v, %v, want claimed", claimed, err)
+	}
+	if n := len(sessions.(*memorySessionRepository).sessions); n != 1 {
+		t.Fatalf("%d sessions kept, want 1", n)
+	}
+}
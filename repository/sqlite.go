@@ -0,0 +1,65 @@
+package repository
+
+import (
+	"context"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// sqliteTitleStore shares the schema, and so the dense ordinal selection, of the Postgres store.
+// SQLite has no row locks, writes are serialized by the database instead.
+type sqliteTitleStore struct {
+	postgresTitleStore
+}
+
+func (s sqliteTitleStore) UpdatePageViews(ctx context.Context, views map[string]int64) error {
+	if len(views) == 0 {
+		return nil
+	}
+	return s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		for title, n := range views {
+			err := s.titles(tx).
+				Where("title = ?", title).
+				Update("page_views", n).Error
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// Search ranks the prefix matches first, then the titles containing the query.
+// SQLite has no trigram matching.
+func (s sqliteTitleStore) Search(ctx context.Context, query string, limit int, offset int) ([]string, error) {
+	escaped := escapeLike(query)
+	var titles []string
+	err := s.titles(s.db.WithContext(ctx)).
+		Where(`title LIKE ? ESCAPE '\'`, "%"+escaped+"%").
+		Clauses(clause.OrderBy{Expression: clause.Expr{
+			SQL:  `title LIKE ? ESCAPE '\' DESC, LENGTH(title), title`,
+			Vars: []any{escaped + "%"},
+		}}).
+		Limit(limit).
+		Offset(offset).
+		Pluck("title", &titles).Error
+	return titles, err
+}
+
+// NotifyLoaded does nothing, SQLite has no notification channel.
+func (s sqliteTitleStore) NotifyLoaded(context.Context) error {
+	return nil
+}
+
+// WatchLoaded blocks until ctx is done, loads are only picked up by periodic count refreshes.
+func (s sqliteTitleStore) WatchLoaded(ctx context.Context, _ func()) error {
+	<-ctx.Done()
+	return nil
+}
+
+// NewSQLiteTitleStore stores the titles of the Wikipedia edition in the given language,
+// in a database opened with database.OpenSQLite.
+func NewSQLiteTitleStore(db *gorm.DB, lang string) TitleStore {
+	return sqliteTitleStore{postgresTitleStore{db: db, lang: lang}}
+}
@@ -0,0 +1,159 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+
+	"knowledgeleaf/database"
+)
+
+// StatsRepository keeps the served article counters and the loader history,
+// shared across application instances.
+type StatsRepository interface {
+	// RecordViews counts a view of each of the titles of the Wikipedia edition in the given language.
+	RecordViews(ctx context.Context, lang string, titles []string) error
+	TotalViews(ctx context.Context) (int64, error)
+	TopViews(ctx context.Context, limit int) ([]database.ArticleView, error)
+	RecordLoaderRun(ctx context.Context, run database.LoaderRun) error
+	LastLoaderRun(ctx context.Context) (database.LoaderRun, error)
+}
+
+type postgresStatsRepository struct {
+	db *gorm.DB
+}
+
+func (p postgresStatsRepository) RecordViews(ctx context.Context, lang string, titles []string) error {
+	if len(titles) == 0 {
+		return nil
+	}
+	now := time.Now().UTC()
+	rows := make([]*database.ArticleView, 0, len(titles))
+	for _, title := range titles {
+		rows = append(rows, &database.ArticleView{Lang: lang, Title: title, Views: 1, LastViewedAt: now})
+	}
+	return p.db.WithContext(ctx).
+		Clauses(clause.OnConflict{
+			Columns: []clause.Column{{Name: "lang"}, {Name: "title"}},
+			DoUpdates: clause.Assignments(map[string]any{
+				"views":          gorm.Expr("article_views.views + 1"),
+				"last_viewed_at": now,
+			}),
+		}).
+		Create(rows).Error
+}
+
+func (p postgresStatsRepository) TotalViews(ctx context.Context) (int64, error) {
+	var n int64
+	err := p.db.WithContext(ctx).Raw("SELECT COALESCE(SUM(views), 0) FROM article_views").Scan(&n).Error
+	return n, err
+}
+
+func (p postgresStatsRepository) TopViews(ctx context.Context, limit int) ([]database.ArticleView, error) {
+	var views []database.ArticleView
+	err := p.db.WithContext(ctx).Order("views DESC").Limit(limit).Find(&views).Error
+	return views, err
+}
+
+func (p postgresStatsRepository) RecordLoaderRun(ctx context.Context, run database.LoaderRun) error {
+	return p.db.WithContext(ctx).Create(&run).Error
+}
+
+func (p postgresStatsRepository) LastLoaderRun(ctx context.Context) (database.LoaderRun, error) {
+	var run database.LoaderRun
+	err := p.db.WithContext(ctx).Order("completed_at DESC").First(&run).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return database.LoaderRun{}, ErrNotFound
+	}
+	return run, err
+}
+
+func NewPostgresStatsRepository(db *gorm.DB) StatsRepository {
+	return postgresStatsRepository{db: db}
+}
+
+const (
+	// redisKeyArticleViews ranks the titles prefixed with their language, such as "de:Berlin"
+	redisKeyArticleViews = "stats:article_views:by_language"
+	redisKeyTotalViews   = "stats:views_total"
+	redisKeyLoaderRun    = "stats:loader:last_run"
+)
+
+type redisStatsRepository struct {
+	client *redis.Client
+}
+
+func (r redisStatsRepository) RecordViews(ctx context.Context, lang string, titles []string) error {
+	if len(titles) == 0 {
+		return nil
+	}
+	_, err := r.client.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		for _, title := range titles {
+			pipe.ZIncrBy(ctx, redisKeyArticleViews, 1, lang+":"+title)
+		}
+		pipe.IncrBy(ctx, redisKeyTotalViews, int64(len(titles)))
+		return nil
+	})
+	return err
+}
+
+func (r redisStatsRepository) TotalViews(ctx context.Context) (int64, error) {
+	n, err := r.client.Get(ctx, redisKeyTotalViews).Int64()
+	if errors.Is(err, redis.Nil) {
+		return 0, nil
+	}
+	return n, err
+}
+
+func (r redisStatsRepository) TopViews(ctx context.Context, limit int) ([]database.ArticleView, error) {
+	members, err := r.client.ZRevRangeWithScores(ctx, redisKeyArticleViews, 0, int64(limit-1)).Result()
+	if err != nil {
+		return nil, err
+	}
+	views := make([]database.ArticleView, 0, len(members))
+	for _, m := range members {
+		member, _ := m.Member.(string)
+		lang, title, _ := strings.Cut(member, ":")
+		views = append(views, database.ArticleView{Lang: lang, Title: title, Views: int64(m.Score)})
+	}
+	return views, nil
+}
+
+func (r redisStatsRepository) RecordLoaderRun(ctx context.Context, run database.LoaderRun) error {
+	return r.client.HSet(ctx, redisKeyLoaderRun, map[string]any{
+		"title_count":  run.TitleCount,
+		"started_at":   run.StartedAt.UTC().Format(time.RFC3339),
+		"completed_at": run.CompletedAt.UTC().Format(time.RFC3339),
+	}).Err()
+}
+
+func (r redisStatsRepository) LastLoaderRun(ctx context.Context) (database.LoaderRun, error) {
+	fields, err := r.client.HGetAll(ctx, redisKeyLoaderRun).Result()
+	if err != nil {
+		return database.LoaderRun{}, err
+	}
+	if len(fields) == 0 {
+		return database.LoaderRun{}, ErrNotFound
+	}
+	var run database.LoaderRun
+	if run.TitleCount, err = strconv.ParseInt(fields["title_count"], 10, 64); err != nil {
+		return database.LoaderRun{}, err
+	}
+	if run.StartedAt, err = time.Parse(time.RFC3339, fields["started_at"]); err != nil {
+		return database.LoaderRun{}, err
+	}
+	if run.CompletedAt, err = time.Parse(time.RFC3339, fields["completed_at"]); err != nil {
+		return database.LoaderRun{}, err
+	}
+	return run, nil
+}
+
+func NewRedisStatsRepository(client *redis.Client) StatsRepository {
+	return redisStatsRepository{client: client}
+}
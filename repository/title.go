@@ -3,108 +3,473 @@ package repository
 import (
 	"context"
 	"errors"
+	"fmt"
+	"math/rand"
+	"strings"
 	"time"
 
 	"github.com/google/uuid"
-	"github.com/jackc/pgerrcode"
-	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/stdlib"
 	"gorm.io/gorm"
 	"gorm.io/gorm/clause"
 
 	"knowledgeleaf/database"
 )
 
-type Repository interface {
-	BulkCreate(context.Context, []string) error
-	CurrentBucketValue(context.Context) (int64, error)
-	NextBucketValue(context.Context) (int64, error)
+// TitleStore is the set of article titles random trivia is selected from.
+type TitleStore interface {
+	// Random returns a uniformly selected title, or ErrNotFound when the store is empty.
+	Random(context.Context) (string, error)
+	Count(context.Context) (int64, error)
+	Exists(ctx context.Context, title string) (bool, error)
+	Add(ctx context.Context, titles ...string) error
+	Remove(ctx context.Context, titles ...string) error
+	// Iterate calls fn for every title, in no particular order, until fn returns false.
+	Iterate(ctx context.Context, fn func(title string) bool) error
 }
 
+// SeekableTitleStore selects titles deterministically.
+type SeekableTitleStore interface {
+	// TitleAt returns the title at position n, modulo the title count.
+	// Positions are stable as long as the stored titles do not change.
+	TitleAt(ctx context.Context, n uint64) (string, error)
+}
+
+// WeightedTitleStore keeps a popularity score per title and selects titles weighted by it.
+type WeightedTitleStore interface {
+	// UpdatePageViews sets the popularity score of existing titles.
+	UpdatePageViews(ctx context.Context, views map[string]int64) error
+	// IndexWeights precomputes the selection weight of every title, its page views raised to exponent.
+	// Titles added, or whose page views changed, afterwards keep their previous weight until indexed again.
+	IndexWeights(ctx context.Context, exponent float64) error
+	// WeightedRandom selects a title with probability proportional to its indexed weight.
+	// It returns ErrNotFound when no title has any weight.
+	WeightedRandom(ctx context.Context) (string, error)
+}
+
+// StagedTitleStore loads titles without exposing them until the load is complete.
+type StagedTitleStore interface {
+	Stage(ctx context.Context, titles []string) error
+	// Commit atomically replaces the stored titles with the staged ones.
+	Commit(context.Context) error
+}
+
+// RejectingTitleStore remembers the titles removed by quality filters,
+// so that loading the titles again does not restore them.
+type RejectingTitleStore interface {
+	Reject(ctx context.Context, title string, reason string) error
+}
+
+// SearchableTitleStore finds titles by a partial title.
+type SearchableTitleStore interface {
+	// Search returns the titles starting with query, case-insensitively, followed by the titles
+	// otherwise matching it. Both queries and titles are in the underscore separated form.
+	Search(ctx context.Context, query string, limit int, offset int) ([]string, error)
+}
+
+// TitleLoadNotifier announces completed title loads to the running instances.
+type TitleLoadNotifier interface {
+	NotifyLoaded(context.Context) error
+	// WatchLoaded calls fn for every announced load, until ctx is done or the subscription fails.
+	WatchLoaded(ctx context.Context, fn func()) error
+}
+
+// TitlesLoadedChannel is the Redis pub/sub and Postgres NOTIFY channel of completed title loads.
+const TitlesLoadedChannel = "titles_loaded"
+
 type Title struct {
 	ID        string
 	Title     string
-	NumericID int
+	Ordinal   int64
 	CreatedAt time.Time
 	UpdatedAt time.Time
 }
 
-type postgresRepository struct {
-	db *gorm.DB
+// maxOrdinalLookups bounds the lookups of an ordinal that may have been
+// moved by a concurrent removal.
+const maxOrdinalLookups = 3
+
+const iterateBatchSize = 1000
+
+// maxWeightedDraws bounds the weighted draws, which may fall into the weight of removed titles.
+const maxWeightedDraws = 5
+
+// postgresTitleStore assigns every title a dense ordinal, from 1 up to the title count,
+// so that a uniformly random title is a single index lookup.
+// Ordinals are assigned and compacted while holding a lock on the counter row of the language.
+type postgresTitleStore struct {
+	db   *gorm.DB
+	lang string
+}
+
+// titles scopes a query to the titles of the store language.
+func (p postgresTitleStore) titles(tx *gorm.DB) *gorm.DB {
+	return tx.Model(&database.WikipediaTitle{}).Where("lang = ?", p.lang)
+}
+
+func (p postgresTitleStore) Random(ctx context.Context) (string, error) {
+	for i := 0; i < maxOrdinalLookups; i++ {
+		n, err := p.Count(ctx)
+		if err != nil {
+			return "", err
+		}
+		if n <= 0 {
+			return "", ErrNotFound
+		}
+		title, err := p.titleByOrdinal(ctx, rand.Int63n(n)+1)
+		if !errors.Is(err, ErrNotFound) {
+			return title, err
+		}
+	}
+	return "", ErrNotFound
 }
 
-const sequenceNameBucketID = "wikipedia_titles_numeric_id_seq"
+func (p postgresTitleStore) TitleAt(ctx context.Context, n uint64) (string, error) {
+	for i := 0; i < maxOrdinalLookups; i++ {
+		count, err := p.Count(ctx)
+		if err != nil {
+			return "", err
+		}
+		if count <= 0 {
+			return "", ErrNotFound
+		}
+		title, err := p.titleByOrdinal(ctx, int64(n%uint64(count))+1)
+		if !errors.Is(err, ErrNotFound) {
+			return title, err
+		}
+	}
+	return "", ErrNotFound
+}
 
-func (p postgresRepository) BulkCreate(ctx context.Context, titles []string) error {
+func (p postgresTitleStore) titleByOrdinal(ctx context.Context, ordinal int64) (string, error) {
+	var titles []string
+	err := p.titles(p.db.WithContext(ctx)).
+		Where("ordinal = ?", ordinal).
+		Pluck("title", &titles).Error
+	if err != nil {
+		return "", err
+	}
 	if len(titles) == 0 {
-		return nil
+		return "", ErrNotFound
 	}
+	return titles[0], nil
+}
 
-	var existingCount int64
-	existingArticleCountQuery := p.db.WithContext(ctx).Model(&database.WikipediaTitle{}).
-		Where("title IN ?", titles)
-	if err := existingArticleCountQuery.Count(&existingCount).Error; err != nil {
-		return err
+// Count returns the number of titles, which is also the highest title ordinal.
+func (p postgresTitleStore) Count(ctx context.Context) (int64, error) {
+	var counter database.WikipediaTitleCounter
+	err := p.db.WithContext(ctx).First(&counter, "lang = ?", p.lang).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		// No title of the language has been loaded yet
+		return 0, nil
 	}
-	diff := len(titles) - int(existingCount)
-	if diff <= 0 {
+	return counter.Value, err
+}
+
+func (p postgresTitleStore) Exists(ctx context.Context, title string) (bool, error) {
+	var n int64
+	err := p.titles(p.db.WithContext(ctx)).Where("title = ?", title).Count(&n).Error
+	return n > 0, err
+}
+
+// Add inserts the titles that neither exist nor have been rejected,
+// assigning them the ordinals following the current highest one.
+func (p postgresTitleStore) Add(ctx context.Context, titles ...string) error {
+	if len(titles) == 0 {
 		return nil
 	}
 
-	var bucketID int64
-	if diff <= 50 {
-		b, err := p.CurrentBucketValue(ctx)
+	return p.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		counter, err := p.lockTitleCounter(tx)
 		if err != nil {
-			var pgErr *pgconn.PgError
-			if !errors.As(err, &pgErr) || pgErr.Code != pgerrcode.ObjectNotInPrerequisiteState {
-				return err
+			return err
+		}
+
+		var existing []string
+		err = p.titles(tx).
+			Where("title IN ?", titles).
+			Pluck("title", &existing).Error
+		if err != nil {
+			return err
+		}
+		var rejected []string
+		err = tx.Model(&database.RejectedTitle{}).
+			Where("lang = ? AND title IN ?", p.lang, titles).
+			Pluck("title", &rejected).Error
+		if err != nil {
+			return err
+		}
+		skip := make(map[string]struct{}, len(titles))
+		for _, title := range append(existing, rejected...) {
+			skip[title] = struct{}{}
+		}
+		rows := make([]*database.WikipediaTitle, 0, len(titles))
+		for _, item := range titles {
+			if _, ok := skip[item]; ok {
+				continue
 			}
-		} else {
-			bucketID = b
+			skip[item] = struct{}{}
+			rows = append(rows, &database.WikipediaTitle{
+				ID:      uuid.NewString(),
+				Lang:    p.lang,
+				Title:   item,
+				Ordinal: counter.Value + int64(len(rows)) + 1,
+			})
+		}
+		if len(rows) == 0 {
+			return nil
+		}
+
+		if err := tx.Create(rows).Error; err != nil {
+			return err
 		}
+		return tx.Model(&counter).Update("value", counter.Value+int64(len(rows))).Error
+	})
+}
+
+// Remove deletes the titles, moving the title with the highest ordinal into each freed ordinal.
+func (p postgresTitleStore) Remove(ctx context.Context, titles ...string) error {
+	if len(titles) == 0 {
+		return nil
 	}
-	if bucketID == 0 {
-		b, err := p.NextBucketValue(ctx)
+	return p.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		return p.removeTitles(tx, titles)
+	})
+}
+
+func (p postgresTitleStore) Reject(ctx context.Context, title string, reason string) error {
+	return p.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		err := tx.Clauses(clause.OnConflict{DoNothing: true}).
+			Create(&database.RejectedTitle{Lang: p.lang, Title: title, Reason: reason}).Error
 		if err != nil {
 			return err
 		}
-		bucketID = b
+		return p.removeTitles(tx, []string{title})
+	})
+}
+
+func (p postgresTitleStore) removeTitles(tx *gorm.DB, titles []string) error {
+	counter, err := p.lockTitleCounter(tx)
+	if err != nil {
+		return err
+	}
+	last := counter.Value
+	for _, title := range titles {
+		var removed database.WikipediaTitle
+		err := tx.Clauses(clause.Returning{}).
+			Where("lang = ? AND title = ?", p.lang, title).
+			Delete(&removed).Error
+		if err != nil {
+			return err
+		}
+		if removed.Ordinal == 0 {
+			continue
+		}
+		if removed.Ordinal != last {
+			err := p.titles(tx).
+				Where("ordinal = ?", last).
+				Update("ordinal", removed.Ordinal).Error
+			if err != nil {
+				return err
+			}
+		}
+		last--
+	}
+	if last == counter.Value {
+		return nil
 	}
-	rows := make([]*database.WikipediaTitle, 0, len(titles))
-	for _, item := range titles {
-		rows = append(rows, &database.WikipediaTitle{
-			ID:        uuid.NewString(),
-			Title:     item,
-			NumericID: int(bucketID),
-		})
+	return tx.Model(&counter).Update("value", last).Error
+}
+
+// lockTitleCounter locks the counter row of the store language, creating it on the first load.
+func (p postgresTitleStore) lockTitleCounter(tx *gorm.DB) (database.WikipediaTitleCounter, error) {
+	err := tx.Clauses(clause.OnConflict{DoNothing: true}).
+		Create(&database.WikipediaTitleCounter{Lang: p.lang}).Error
+	if err != nil {
+		return database.WikipediaTitleCounter{}, err
 	}
+	var counter database.WikipediaTitleCounter
+	err = tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+		First(&counter, "lang = ?", p.lang).Error
+	return counter, err
+}
 
-	tx := p.db.WithContext(ctx).
-		Clauses(clause.OnConflict{DoNothing: true}).
-		Create(rows)
-	if err := tx.Error; err != nil {
-		var pgErr *pgconn.PgError
-		if errors.As(err, &pgErr) {
-			if pgErr.Code == pgerrcode.UniqueViolation {
+// Iterate walks the titles in ordinal order, in batches.
+func (p postgresTitleStore) Iterate(ctx context.Context, fn func(title string) bool) error {
+	var after int64
+	for {
+		var rows []database.WikipediaTitle
+		err := p.titles(p.db.WithContext(ctx)).
+			Select("title", "ordinal").
+			Where("ordinal > ?", after).
+			Order("ordinal").
+			Limit(iterateBatchSize).
+			Find(&rows).Error
+		if err != nil {
+			return err
+		}
+		for _, row := range rows {
+			if !fn(row.Title) {
 				return nil
 			}
+			after = row.Ordinal
+		}
+		if len(rows) < iterateBatchSize {
+			return nil
 		}
-		return tx.Error
 	}
-	return nil
 }
 
-func (p postgresRepository) CurrentBucketValue(ctx context.Context) (int64, error) {
-	var n int64
-	err := p.db.WithContext(ctx).Raw("SELECT CURRVAL(?)", sequenceNameBucketID).Scan(&n).Error
-	return n, err
+func (p postgresTitleStore) UpdatePageViews(ctx context.Context, views map[string]int64) error {
+	if len(views) == 0 {
+		return nil
+	}
+	values := make([]string, 0, len(views))
+	args := make([]any, 0, 2*len(views))
+	for title, n := range views {
+		values = append(values, "(?, ?::BIGINT)")
+		args = append(args, title, n)
+	}
+	return p.db.WithContext(ctx).Exec(
+		`UPDATE wikipedia_titles SET page_views = v.page_views, updated_at = CURRENT_TIMESTAMP
+		FROM (VALUES `+strings.Join(values, ", ")+`) AS v(title, page_views)
+		WHERE wikipedia_titles.lang = ? AND wikipedia_titles.title = v.title`, append(args, p.lang)...).Error
 }
 
-func (p postgresRepository) NextBucketValue(ctx context.Context) (int64, error) {
-	var n int64
-	err := p.db.WithContext(ctx).Raw("SELECT NEXTVAL(?)", sequenceNameBucketID).Scan(&n).Error
-	return n, err
+// IndexWeights lays the weights of the titles with page views end to end, in ordinal order,
+// each title owning the interval (weight_from, weight_to] of the cumulative weights.
+func (p postgresTitleStore) IndexWeights(ctx context.Context, exponent float64) error {
+	return p.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		counter, err := p.lockTitleCounter(tx)
+		if err != nil {
+			return err
+		}
+		err = p.titles(tx).
+			Where("page_views = 0 AND weight_to IS NOT NULL").
+			Updates(map[string]any{"weight_from": nil, "weight_to": nil}).Error
+		if err != nil {
+			return err
+		}
+		err = tx.Exec(`UPDATE wikipedia_titles SET weight_from = w.weight_to - w.weight, weight_to = w.weight_to
+			FROM (
+				SELECT id, POWER(page_views, ?) AS weight, SUM(POWER(page_views, ?)) OVER (ORDER BY ordinal) AS weight_to
+				FROM wikipedia_titles
+				WHERE lang = ? AND page_views > 0
+			) AS w
+			WHERE wikipedia_titles.id = w.id`, exponent, exponent, p.lang).Error
+		if err != nil {
+			return err
+		}
+		var total []float64
+		err = p.titles(tx).
+			Where("weight_to IS NOT NULL").
+			Order("weight_to DESC").
+			Limit(1).
+			Pluck("weight_to", &total).Error
+		if err != nil {
+			return err
+		}
+		counter.TotalWeight = 0
+		if len(total) > 0 {
+			counter.TotalWeight = total[0]
+		}
+		return tx.Model(&counter).Update("total_weight", counter.TotalWeight).Error
+	})
+}
+
+// WeightedRandom draws a number up to the total weight, and looks up the title whose interval holds it.
+// Removed titles leave holes in the cumulative weights until they are indexed again: draws falling
+// into a hole are retried, so that the removed weight is not passed on to the following title.
+func (p postgresTitleStore) WeightedRandom(ctx context.Context) (string, error) {
+	var counter database.WikipediaTitleCounter
+	err := p.db.WithContext(ctx).First(&counter, "lang = ?", p.lang).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return "", ErrNotFound
+	}
+	if err != nil {
+		return "", err
+	}
+	if counter.TotalWeight <= 0 {
+		return "", ErrNotFound
+	}
+	for i := 0; i < maxWeightedDraws; i++ {
+		// Draws are in (0, total], matching the upper-inclusive intervals
+		r := (1 - rand.Float64()) * counter.TotalWeight
+		var rows []database.WikipediaTitle
+		err := p.titles(p.db.WithContext(ctx)).
+			Select("title", "weight_from").
+			Where("weight_to >= ?", r).
+			Order("weight_to").
+			Limit(1).
+			Find(&rows).Error
+		if err != nil {
+			return "", err
+		}
+		if len(rows) == 1 && rows[0].WeightFrom != nil && r > *rows[0].WeightFrom {
+			return rows[0].Title, nil
+		}
+	}
+	return "", ErrNotFound
+}
+
+// Search ranks the prefix matches first, then the titles by trigram similarity.
+func (p postgresTitleStore) Search(ctx context.Context, query string, limit int, offset int) ([]string, error) {
+	prefix := escapeLike(query) + "%"
+	var titles []string
+	err := p.titles(p.db.WithContext(ctx)).
+		Where("title ILIKE ? OR title % ?", prefix, query).
+		Clauses(clause.OrderBy{Expression: clause.Expr{
+			SQL:  "title ILIKE ? DESC, similarity(title, ?) DESC, title",
+			Vars: []any{prefix, query},
+		}}).
+		Limit(limit).
+		Offset(offset).
+		Pluck("title", &titles).Error
+	return titles, err
+}
+
+// escapeLike escapes the LIKE wildcards, using the default backslash escape character.
+func escapeLike(s string) string {
+	return strings.NewReplacer(`\`, `\\`, "%", `\%`, "_", `\_`).Replace(s)
+}
+
+func (p postgresTitleStore) NotifyLoaded(ctx context.Context) error {
+	return p.db.WithContext(ctx).Exec("SELECT pg_notify(?, '')", TitlesLoadedChannel).Error
+}
+
+// WatchLoaded holds a pool connection for as long as it listens.
+func (p postgresTitleStore) WatchLoaded(ctx context.Context, fn func()) error {
+	sqlDB, err := p.db.DB()
+	if err != nil {
+		return err
+	}
+	conn, err := sqlDB.Conn(ctx)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+	return conn.Raw(func(driverConn any) error {
+		stdlibConn, ok := driverConn.(*stdlib.Conn)
+		if !ok {
+			return fmt.Errorf("unexpected Postgres driver connection %T", driverConn)
+		}
+		pgConn := stdlibConn.Conn()
+		if _, err := pgConn.Exec(ctx, "LISTEN "+TitlesLoadedChannel); err != nil {
+			return err
+		}
+		for {
+			if _, err := pgConn.WaitForNotification(ctx); err != nil {
+				if ctx.Err() != nil {
+					return nil
+				}
+				return err
+			}
+			fn()
+		}
+	})
 }
 
-func NewPostgresRepository(db *gorm.DB) Repository {
-	return postgresRepository{db: db}
+// NewPostgresTitleStore stores the titles of the Wikipedia edition in the given language.
+func NewPostgresTitleStore(db *gorm.DB, lang string) TitleStore {
+	return postgresTitleStore{db: db, lang: lang}
 }
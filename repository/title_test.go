@@ -0,0 +1,213 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"math/rand"
+	"os"
+	"path/filepath"
+	"slices"
+	"sync"
+	"testing"
+
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+
+	"knowledgeleaf/database"
+)
+
+// samplesPerTitle is the expected number of draws of each title in the uniformity checks.
+const samplesPerTitle = 200
+
+// chiSquareZ is the standard normal quantile of the significance level of the uniformity checks, 0.0001.
+const chiSquareZ = 3.719
+
+func TestTitleStoreUniformSelection(t *testing.T) {
+	stores := map[string]func(t *testing.T) TitleStore{
+		"memory": func(*testing.T) TitleStore {
+			return NewMemoryTitleStore(nil)
+		},
+		"sqlite": func(t *testing.T) TitleStore {
+			db, err := database.OpenSQLite(filepath.Join(t.TempDir(), "titles.db"))
+			if err != nil {
+				t.Fatal(err)
+			}
+			return NewSQLiteTitleStore(db, "en")
+		},
+	}
+	for name, newStore := range stores {
+		t.Run(name, func(t *testing.T) {
+			ctx := context.Background()
+			store := newStore(t)
+
+			titles := make([]string, 60)
+			for i := range titles {
+				titles[i] = fmt.Sprintf("Title_%02d", i)
+			}
+			if err := store.Add(ctx, titles...); err != nil {
+				t.Fatal(err)
+			}
+			assertDenseTitles(t, store, titles)
+			assertUniformSelection(t, store, titles)
+
+			// Remove titles at the start, middle and end of the ordinals, so that compaction moves titles around
+			removed := []string{titles[0], titles[7], titles[30], titles[31], titles[58], titles[59]}
+			if err := store.Remove(ctx, removed...); err != nil {
+				t.Fatal(err)
+			}
+			excluded := removed
+			if rejecting, ok := store.(RejectingTitleStore); ok {
+				for _, title := range []string{titles[1], titles[44], titles[57]} {
+					if err := rejecting.Reject(ctx, title, "test"); err != nil {
+						t.Fatal(err)
+					}
+					excluded = append(excluded, title)
+				}
+			}
+			var remaining []string
+			for _, title := range titles {
+				if !slices.Contains(excluded, title) {
+					remaining = append(remaining, title)
+				}
+			}
+			assertDenseTitles(t, store, remaining)
+			assertUniformSelection(t, store, remaining)
+		})
+	}
+}
+
+// TestPostgresTitleStoreDenseOrdinals runs against the database of TEST_POSTGRES_DSN, such as
+// "host=localhost user=knowledge_leaf dbname=knowledge_leaf", migrated with run-postgres-migrations.sh.
+// It is skipped without it.
+// Titles are stored under a throwaway language, deleted once the test completes.
+func TestPostgresTitleStoreDenseOrdinals(t *testing.T) {
+	dsn := os.Getenv("TEST_POSTGRES_DSN")
+	if dsn == "" {
+		t.Skip("TEST_POSTGRES_DSN is not set")
+	}
+	db, err := gorm.Open(postgres.Open(dsn), &gorm.Config{Logger: logger.Default.LogMode(logger.Silent)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	lang := fmt.Sprintf("test-%06d", rand.Intn(1_000_000))
+	t.Cleanup(func() {
+		for _, table := range []string{"wikipedia_titles", "wikipedia_title_counter", "rejected_titles"} {
+			if err := db.Exec("DELETE FROM "+table+" WHERE lang = ?", lang).Error; err != nil {
+				t.Error(err)
+			}
+		}
+	})
+	ctx := context.Background()
+	store := NewPostgresTitleStore(db, lang)
+
+	titles := make([]string, 200)
+	for i := range titles {
+		titles[i] = fmt.Sprintf("Title_%03d", i)
+	}
+	// Concurrent adds and removes contend on the counter row
+	var wg sync.WaitGroup
+	for batch := range slices.Chunk(titles, 20) {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if err := store.Add(ctx, batch...); err != nil {
+				t.Error(err)
+			}
+		}()
+	}
+	wg.Wait()
+	assertDenseTitles(t, store, titles)
+
+	var removed []string
+	for i := 0; i < len(titles); i += 7 {
+		removed = append(removed, titles[i])
+	}
+	for batch := range slices.Chunk(removed, 5) {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if err := store.Remove(ctx, batch...); err != nil {
+				t.Error(err)
+			}
+		}()
+	}
+	wg.Wait()
+	rejected := titles[len(titles)-2]
+	if err := store.(RejectingTitleStore).Reject(ctx, rejected, "test"); err != nil {
+		t.Fatal(err)
+	}
+	var remaining []string
+	for _, title := range titles {
+		if !slices.Contains(removed, title) && title != rejected {
+			remaining = append(remaining, title)
+		}
+	}
+	assertDenseTitles(t, store, remaining)
+	assertUniformSelection(t, store, remaining)
+}
+
+// assertDenseTitles checks that the positions of the store address each of the titles exactly once.
+func assertDenseTitles(t *testing.T, store TitleStore, titles []string) {
+	t.Helper()
+	ctx := context.Background()
+	count, err := store.Count(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if count != int64(len(titles)) {
+		t.Fatalf("count = %d, want %d", count, len(titles))
+	}
+	seekable, ok := store.(SeekableTitleStore)
+	if !ok {
+		return
+	}
+	seen := make(map[string]bool, len(titles))
+	for n := range uint64(count) {
+		title, err := seekable.TitleAt(ctx, n)
+		if err != nil {
+			t.Fatalf("TitleAt(%d): %v", n, err)
+		}
+		if seen[title] {
+			t.Fatalf("TitleAt(%d) = %q, already returned at another position", n, title)
+		}
+		if !slices.Contains(titles, title) {
+			t.Fatalf("TitleAt(%d) = %q, not a stored title", n, title)
+		}
+		seen[title] = true
+	}
+}
+
+// assertUniformSelection draws random titles and runs a chi-square goodness-of-fit test against the uniform distribution.
+func assertUniformSelection(t *testing.T, store TitleStore, titles []string) {
+	t.Helper()
+	ctx := context.Background()
+	counts := make(map[string]int, len(titles))
+	samples := samplesPerTitle * len(titles)
+	for range samples {
+		title, err := store.Random(ctx)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !slices.Contains(titles, title) {
+			t.Fatalf("Random() = %q, not a stored title", title)
+		}
+		counts[title]++
+	}
+	var statistic float64
+	for _, title := range titles {
+		diff := float64(counts[title] - samplesPerTitle)
+		statistic += diff * diff / samplesPerTitle
+	}
+	if critical := chiSquareCritical(len(titles) - 1); statistic > critical {
+		t.Fatalf("chi-square statistic %.1f exceeds %.1f, selection is not uniform: %v", statistic, critical, counts)
+	}
+}
+
+// chiSquareCritical approximates the critical value of the chi-square distribution with df degrees of freedom
+// at the chiSquareZ significance level, using the Wilson–Hilferty transformation.
+func chiSquareCritical(df int) float64 {
+	k := float64(df)
+	return k * math.Pow(1-2/(9*k)+chiSquareZ*math.Sqrt(2/(9*k)), 3)
+}
@@ -0,0 +1,63 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"net/url"
+
+	"knowledgeleaf/repository"
+)
+
+// ErrSearchUnsupported is returned when the title store cannot be searched.
+var ErrSearchUnsupported = errors.New("title search is not supported by the title store")
+
+const (
+	defaultSearchLimit = 10
+	maxSearchLimit     = 50
+	maxSearchOffset    = 1000
+	maxSearchQueryLen  = 255
+)
+
+// searchTitles returns a page of the titles of a language matching the query.
+func searchTitles(
+	ctx context.Context,
+	triviaBackend *RandomTriviaBackend,
+	lang string,
+	query string,
+	limit int,
+	offset int,
+) (TitleSearchResponse, error) {
+	titles, err := triviaBackend.titles(lang)
+	if err != nil {
+		return TitleSearchResponse{}, err
+	}
+	store, ok := titles.(repository.SearchableTitleStore)
+	if !ok {
+		return TitleSearchResponse{}, ErrSearchUnsupported
+	}
+	// One more title is requested to find out whether there is a next page
+	matches, err := store.Search(ctx, seenKey(query), limit+1, offset)
+	if err != nil {
+		return TitleSearchResponse{}, err
+	}
+	resp := TitleSearchResponse{Results: []TitleSearchResult{}}
+	if len(matches) > limit {
+		matches = matches[:limit]
+		if next := offset + limit; next <= maxSearchOffset {
+			resp.NextOffset = &next
+		}
+	}
+	for _, title := range matches {
+		resp.Results = append(resp.Results, TitleSearchResult{
+			Title:      titleKey(title),
+			AppLinkURL: articleAppLinkURL(triviaBackend.language(lang), title),
+		})
+	}
+	return resp, nil
+}
+
+// articleAppLinkURL returns the Knowledge Leaf permalink of an article. The language is always part of
+// the permalink, so that it does not depend on the default language.
+func articleAppLinkURL(lang string, title string) string {
+	return "/articles/" + url.PathEscape(seenKey(title)) + "?" + url.Values{"lang": {lang}}.Encode()
+}
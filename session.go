@@ -0,0 +1,232 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/rand"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+
+	"knowledgeleaf/app"
+	"knowledgeleaf/repository"
+)
+
+const (
+	sessionCookieName = "kl_session"
+	sessionHeader     = "X-Session-ID"
+	// The complete title set is scanned for unseen titles once at most maxUnseenScan titles,
+	// or at most one title in unseenScanRatio, are left unseen, as random draws would mostly hit seen titles.
+	maxUnseenScan   = 10_000
+	unseenScanRatio = 20
+	// maxUnseenDraws bounds the random draws made while many titles are left unseen.
+	maxUnseenDraws = 100
+	// sessionPurgeInterval is the interval between two purges of the expired session entries.
+	sessionPurgeInterval = time.Hour
+)
+
+// clientSession returns the anonymous session identifier of the client, sent either
+// as a header or a cookie. Clients without a valid identifier are assigned a new one.
+func clientSession(w http.ResponseWriter, r *http.Request, ttl time.Duration) string {
+	id := r.Header.Get(sessionHeader)
+	if id == "" {
+		if c, err := r.Cookie(sessionCookieName); err == nil {
+			id = c.Value
+		}
+	}
+	if _, err := uuid.Parse(id); err != nil {
+		id = uuid.NewString()
+	}
+	http.SetCookie(w, &http.Cookie{
+		Name:     sessionCookieName,
+		Value:    id,
+		Path:     "/",
+		MaxAge:   int(ttl.Seconds()),
+		HttpOnly: true,
+		SameSite: http.SameSiteLaxMode,
+	})
+	w.Header().Set(sessionHeader, id)
+	return id
+}
+
+// seenKey returns the form of a title stored in the session seen-sets, matching the title stores.
+func seenKey(title string) string {
+	return strings.ReplaceAll(title, " ", "_")
+}
+
+// sessionTitleKey returns the entry of a title in the session seen-sets. Titles are prefixed with
+// their language, as the same title in two Wikipedia editions is a different article.
+func (b *RandomTriviaBackend) sessionTitleKey(lang string, title string) string {
+	return b.language(lang) + ":" + seenKey(title)
+}
+
+// claimForSession marks a title of the language as seen by the session, unless it has already been seen.
+// Concurrent claims of the same title succeed at most once.
+func (b *RandomTriviaBackend) claimForSession(ctx context.Context, session string, lang string, title string) (bool, error) {
+	return b.application.Sessions.MarkSeen(ctx, session, b.sessionTitleKey(lang, title))
+}
+
+// forgetForSession releases a title claimed for the session whose article could not be served,
+// so that it can still be shown to the session later.
+func (b *RandomTriviaBackend) forgetForSession(ctx context.Context, session string, lang string, title string) {
+	if err := b.application.Sessions.Forget(ctx, session, b.sessionTitleKey(lang, title)); err != nil {
+		app.LoggerFromContext(ctx).Warn("releasing session title failed",
+			zap.Error(err), zap.String("session", session), zap.String("title", title))
+	}
+}
+
+// PurgeExpiredSessions deletes the expired session entries every sessionPurgeInterval, until ctx is done,
+// when the session repository does not expire them on its own.
+func (b *RandomTriviaBackend) PurgeExpiredSessions(ctx context.Context) {
+	purger, ok := b.application.Sessions.(repository.PurgingSessionRepository)
+	if !ok {
+		return
+	}
+	logger := b.application.Logger
+	go func() {
+		ticker := time.NewTicker(sessionPurgeInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				n, err := purger.PurgeExpired(ctx)
+				if err != nil {
+					if ctx.Err() == nil {
+						logger.Error("purging expired sessions failed", zap.Error(err))
+					}
+					continue
+				}
+				logger.Info(fmt.Sprintf("purged %d expired session titles", n))
+			}
+		}
+	}()
+}
+
+// unseenTitle selects a title the session has not seen yet, once random picks kept hitting seen titles.
+// While many titles are unseen, it keeps drawing random titles. It only looks at the complete title set
+// when few titles are left unseen, and starts over once the session has seen every title.
+func (b *RandomTriviaBackend) unseenTitle(ctx context.Context, query TitleQuery, claim func(string) bool) (string, error) {
+	if len(query.Categories) == 0 {
+		total, unseen, err := b.unseenCount(ctx, query)
+		if err != nil {
+			return "", err
+		}
+		if unseen > maxUnseenScan && unseen*unseenScanRatio > total {
+			return b.randomUnseenDraw(ctx, query, claim)
+		}
+	}
+
+	title, err := b.randomUnseenTitle(ctx, query)
+	if errors.Is(err, repository.ErrNotFound) && len(query.Categories) == 0 {
+		app.LoggerFromContext(ctx).Info("session has seen every title - starting over",
+			zap.String("session", query.Session))
+		if err := b.application.Sessions.Reset(ctx, query.Session); err != nil {
+			return "", err
+		}
+		title, err = b.RandomTitle(ctx, query)
+	}
+	if err != nil {
+		return "", err
+	}
+	if !claim(title) {
+		return "", &ArticleError{Err: errNoDistinctTitle}
+	}
+	claimed, err := b.claimForSession(ctx, query.Session, query.Lang, title)
+	if err != nil {
+		return "", err
+	}
+	if !claimed {
+		return "", &ArticleError{Err: errNoDistinctTitle}
+	}
+	return title, nil
+}
+
+// unseenCount returns the title count, along with an estimate of the number of titles
+// the session has not seen yet. Titles seen in other languages are counted as seen,
+// which only lowers the estimate.
+func (b *RandomTriviaBackend) unseenCount(ctx context.Context, query TitleQuery) (int64, int64, error) {
+	titles, err := b.titles(query.Lang)
+	if err != nil {
+		return 0, 0, err
+	}
+	total, err := titles.Count(ctx)
+	if err != nil {
+		return 0, 0, err
+	}
+	seen, err := b.application.Sessions.SeenCount(ctx, query.Session)
+	if err != nil {
+		return 0, 0, err
+	}
+	return total, total - seen, nil
+}
+
+// randomUnseenDraw keeps drawing random titles until one is claimed for the session, up to maxUnseenDraws times.
+func (b *RandomTriviaBackend) randomUnseenDraw(ctx context.Context, query TitleQuery, claim func(string) bool) (string, error) {
+	for i := 0; i < maxUnseenDraws; i++ {
+		query.attempt++
+		title, err := b.RandomTitle(ctx, query)
+		if err != nil {
+			return "", err
+		}
+		if !claim(title) {
+			continue
+		}
+		claimed, err := b.claimForSession(ctx, query.Session, query.Lang, title)
+		if err != nil {
+			return "", err
+		}
+		if claimed {
+			return title, nil
+		}
+	}
+	return "", &ArticleError{Err: errNoDistinctTitle}
+}
+
+// randomUnseenTitle returns repository.ErrNotFound when the session has seen every title.
+// Category filtered selections are not tracked exhaustively.
+func (b *RandomTriviaBackend) randomUnseenTitle(ctx context.Context, query TitleQuery) (string, error) {
+	if len(query.Categories) > 0 {
+		return b.RandomTitle(ctx, query)
+	}
+
+	titles, err := b.titles(query.Lang)
+	if err != nil {
+		return "", err
+	}
+	seenTitles, err := b.application.Sessions.SeenTitles(ctx, query.Session)
+	if err != nil {
+		return "", err
+	}
+	seen := make(map[string]struct{}, len(seenTitles))
+	for _, title := range seenTitles {
+		seen[title] = struct{}{}
+	}
+	// Reservoir sampling selects uniformly among the unseen titles in a single pass
+	var (
+		selected string
+		unseen   int
+	)
+	err = titles.Iterate(ctx, func(title string) bool {
+		if _, ok := seen[b.sessionTitleKey(query.Lang, title)]; ok {
+			return true
+		}
+		unseen++
+		if rand.Intn(unseen) == 0 {
+			selected = title
+		}
+		return true
+	})
+	if err != nil {
+		return "", err
+	}
+	if unseen == 0 {
+		return "", repository.ErrNotFound
+	}
+	return selected, nil
+}
@@ -0,0 +1,87 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"go.uber.org/zap"
+
+	"knowledgeleaf/repository"
+)
+
+const (
+	defaultStatsArticleLimit = 10
+	maxStatsArticleLimit     = 100
+	recordViewsTimeout       = 5 * time.Second
+)
+
+// triviaStats reports the dataset size, the last loader run and the most viewed articles.
+// Loader and view statistics are omitted when no stats store is configured.
+func triviaStats(ctx context.Context, triviaBackend *RandomTriviaBackend, limit int) (TriviaStatsResponse, error) {
+	var resp TriviaStatsResponse
+	backend, count, err := triviaBackend.TitleCount(ctx)
+	if err != nil {
+		return TriviaStatsResponse{}, err
+	}
+	resp.Titles = TitleStats{Backend: backend, Count: count}
+
+	stats := triviaBackend.application.Stats
+	if stats == nil {
+		return resp, nil
+	}
+	run, err := stats.LastLoaderRun(ctx)
+	switch {
+	case err == nil:
+		resp.Loader = &LoaderRun{
+			TitleCount:  run.TitleCount,
+			StartedAt:   run.StartedAt,
+			CompletedAt: run.CompletedAt,
+		}
+	case !errors.Is(err, repository.ErrNotFound):
+		return TriviaStatsResponse{}, err
+	}
+
+	total, err := stats.TotalViews(ctx)
+	if err != nil {
+		return TriviaStatsResponse{}, err
+	}
+	top, err := stats.TopViews(ctx, limit)
+	if err != nil {
+		return TriviaStatsResponse{}, err
+	}
+	resp.Views = &ViewsStats{Total: total, Articles: make([]ArticleViews, 0, len(top))}
+	for _, v := range top {
+		resp.Views.Articles = append(resp.Views.Articles, ArticleViews{Lang: v.Lang, Title: v.Title, Views: v.Views})
+	}
+	return resp, nil
+}
+
+// recordViews increments the view counters of the served articles in the background.
+func recordViews(ctx context.Context, triviaBackend *RandomTriviaBackend, logger *zap.Logger, summaries []WikiSummary) {
+	stats := triviaBackend.application.Stats
+	if stats == nil || len(summaries) == 0 {
+		return
+	}
+	seen := make(map[string]struct{}, len(summaries))
+	titles := make(map[string][]string)
+	for _, summary := range summaries {
+		// Summaries decoded from a cache have no language, they are articles of the default language
+		lang := triviaBackend.language(summary.lang)
+		title := titleKey(summary.Title)
+		if _, ok := seen[lang+":"+title]; ok {
+			continue
+		}
+		seen[lang+":"+title] = struct{}{}
+		titles[lang] = append(titles[lang], title)
+	}
+	go func() {
+		ctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), recordViewsTimeout)
+		defer cancel()
+		for lang, titles := range titles {
+			if err := stats.RecordViews(ctx, lang, titles); err != nil {
+				logger.Warn("recording article views failed", zap.Error(err), zap.String("lang", lang))
+			}
+		}
+	}()
+}
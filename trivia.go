@@ -2,156 +2,446 @@ package main
 
 import (
 	"context"
-	"embed"
-	"encoding/json"
 	"errors"
 	"fmt"
-	"math/rand"
+	"hash/fnv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
 
 	"go.uber.org/zap"
 	"golang.org/x/sync/errgroup"
-	"gorm.io/gorm/clause"
+	"golang.org/x/sync/singleflight"
 
 	"knowledgeleaf/app"
-	"knowledgeleaf/database"
 	"knowledgeleaf/externalapi/wikipedia"
+	"knowledgeleaf/repository"
 )
 
-// https://dumps.wikimedia.org/enwiki/latest/
-//
-//go:embed knowledgebase/samples/wikipedia-article-list-samples-*.json
-var knowledgeBaseFS embed.FS
-
-var wikipediaArticleTitles = func() []string {
-	var titles []string
-	for _, pathSuffix := range []string{"1"} {
-		var elements []string
-		b, err := knowledgeBaseFS.ReadFile(
-			fmt.Sprintf("knowledgebase/samples/wikipedia-article-list-samples-%s.json", pathSuffix))
-		if err != nil {
-			panic(err)
-		}
-		if err := json.Unmarshal(b, &elements); err != nil {
-			panic(err)
-		}
-		titles = append(titles, elements...)
+// ErrCategoryFilterUnsupported is returned when a category filter is requested
+// but no category membership store is configured.
+var ErrCategoryFilterUnsupported = errors.New("category filter requires Postgres")
+
+// ErrCategoryFilterLanguage is returned when a category filter is requested for another language
+// than the default one, as categories are only tracked for the default language.
+var ErrCategoryFilterLanguage = errors.New("category filter is only available in the default language")
+
+// ErrLanguageUnsupported is returned when titles are requested for a language that is not served.
+var ErrLanguageUnsupported = errors.New("language not available")
+
+const (
+	maxCategoryFilters = 10
+	maxSeedLength      = 128
+)
+
+// TitleQuery restricts the random title selection.
+type TitleQuery struct {
+	// Categories limits the selection to titles belonging to any of the given categories.
+	Categories []string
+	// Seed makes the selection deterministic: the same seed and index always
+	// select the same title for the same dataset.
+	Seed  string
+	Index int
+	// Session excludes titles already served to the client session, until every title has been served.
+	Session string
+	// Lang is the language of the Wikipedia edition titles are selected from, the default language when empty.
+	Lang string
+
+	// attempt distinguishes repeated selections of the same seeded query.
+	attempt int
+}
+
+// isUnrestricted reports whether any title may be selected at random.
+func (q TitleQuery) isUnrestricted() bool {
+	return len(q.Categories) == 0 && q.Seed == ""
+}
+
+// seededValue returns the pseudo-random value of a seeded query.
+func (q TitleQuery) seededValue() (uint64, bool) {
+	if q.Seed == "" {
+		return 0, false
 	}
+	h := fnv.New64a()
+	_, _ = fmt.Fprintf(h, "%s\x00%d\x00%d", q.Seed, q.Index, q.attempt)
+	return h.Sum64(), true
+}
 
-	return titles
-}()
+// dailySeed returns the seed shared by every request made on the given UTC date.
+func dailySeed(t time.Time) string {
+	return "daily:" + t.UTC().Format(time.DateOnly)
+}
+
+// normalizeCategory converts a category name to the form returned by the Wikipedia API.
+func normalizeCategory(s string) string {
+	s = strings.TrimSpace(s)
+	s = strings.TrimPrefix(s, "Category:")
+	return strings.ReplaceAll(s, "_", " ")
+}
 
 type RandomTriviaBackend struct {
 	application app.App
-	titleCount  int
+	filters     []ArticleFilter
+	// titleCount caches the title count of the store, see WatchTitleCount
+	titleCount       atomic.Int64
+	titleCountLoaded atomic.Bool
+	// categoryGroup coalesces the concurrent loads of the members of a category
+	categoryGroup singleflight.Group
+}
+
+func NewRandomTriviaBackend(application app.App) (*RandomTriviaBackend, error) {
+	filters, err := NewArticleFilters(application.Cfg)
+	if err != nil {
+		return nil, err
+	}
+	return &RandomTriviaBackend{application: application, filters: filters}, nil
 }
 
-func NewRandomTriviaBackend(application app.App) *RandomTriviaBackend {
-	return &RandomTriviaBackend{application: application}
+// language returns the given language, or the default language when empty.
+func (b *RandomTriviaBackend) language(lang string) string {
+	if lang == "" {
+		return b.application.Cfg.DefaultLanguage()
+	}
+	return lang
 }
 
-func (b *RandomTriviaBackend) RandomTitle(ctx context.Context) (string, error) {
-	if b.application.Cfg.PostgresEnabled {
-		return fetchRandomArticleTitle(ctx, b)
+// titles returns the title store of a language, the default language when empty.
+func (b *RandomTriviaBackend) titles(lang string) (repository.TitleStore, error) {
+	store := b.application.TitlesFor(b.language(lang))
+	if store == nil {
+		return nil, ErrLanguageUnsupported
 	}
+	return store, nil
+}
+
+// Reject excludes a title of a language from future selection. Stores that cannot record
+// the reason only remove the title, until it is loaded again.
+func (b *RandomTriviaBackend) Reject(ctx context.Context, lang string, title string, reason string) error {
+	titles, err := b.titles(lang)
+	if err != nil {
+		return err
+	}
+	if store, ok := titles.(repository.RejectingTitleStore); ok {
+		return store.Reject(ctx, title, reason)
+	}
+	return titles.Remove(ctx, title)
+}
 
-	if !b.application.Cfg.UseRedis {
-		if b.titleCount == 0 {
-			b.titleCount = len(wikipediaArticleTitles)
+// TitleCount returns the name of the store titles are selected from, along with the cached title count
+// of the default language.
+func (b *RandomTriviaBackend) TitleCount(ctx context.Context) (string, int64, error) {
+	backend := string(b.application.Cfg.TitleStore)
+	if b.titleCountLoaded.Load() {
+		return backend, b.titleCount.Load(), nil
+	}
+	n, err := b.RefreshTitleCount(ctx)
+	return backend, n, err
+}
+
+// RefreshTitleCount reads the title count of the default language from the store and caches it.
+func (b *RandomTriviaBackend) RefreshTitleCount(ctx context.Context) (int64, error) {
+	n, err := b.application.DefaultTitles().Count(ctx)
+	if err != nil {
+		return 0, err
+	}
+	if previous := b.titleCount.Swap(n); previous != n || !b.titleCountLoaded.Swap(true) {
+		b.application.Logger.Info(fmt.Sprintf("found %d titles", n),
+			zap.String("titleStore", string(b.application.Cfg.TitleStore)))
+	}
+	return n, nil
+}
+
+// titleCountRetryInterval is the delay before subscribing again to load notifications.
+const titleCountRetryInterval = 10 * time.Second
+
+// WatchTitleCount keeps the cached title count up to date, until ctx is done. The count is refreshed
+// every TITLE_COUNT_REFRESH_INTERVAL, and whenever the store announces a completed load.
+func (b *RandomTriviaBackend) WatchTitleCount(ctx context.Context) {
+	logger := b.application.Logger
+	refresh := func() {
+		if _, err := b.RefreshTitleCount(ctx); err != nil && ctx.Err() == nil {
+			logger.Error("refreshing title count failed", zap.Error(err))
 		}
-		return wikipediaArticleTitles[rand.Intn(b.titleCount)], nil
 	}
+	refresh()
 
-	if b.titleCount == 0 {
-		// TODO: reuse key names between Loader and Fetcher
-		cmd := b.application.RedisClient.SCard(ctx, "datasource:wikipedia")
-		if cmd.Err() != nil {
-			return "", cmd.Err()
+	if interval := b.application.Cfg.TitleCountRefreshInterval; interval > 0 {
+		go func() {
+			ticker := time.NewTicker(interval)
+			defer ticker.Stop()
+			for {
+				select {
+				case <-ctx.Done():
+					return
+				case <-ticker.C:
+					refresh()
+				}
+			}
+		}()
+	}
+
+	notifier, ok := b.application.DefaultTitles().(repository.TitleLoadNotifier)
+	if !ok {
+		return
+	}
+	go func() {
+		for ctx.Err() == nil {
+			if err := notifier.WatchLoaded(ctx, refresh); err != nil {
+				logger.Warn("watching title loads failed - retrying", zap.Error(err))
+			}
+			select {
+			case <-ctx.Done():
+			case <-time.After(titleCountRetryInterval):
+				// Loads may have been missed while not subscribed
+				refresh()
+			}
+		}
+	}()
+}
+
+func (b *RandomTriviaBackend) RandomTitle(ctx context.Context, query TitleQuery) (string, error) {
+	seed, seeded := query.seededValue()
+	if len(query.Categories) > 0 {
+		if b.application.Categories == nil {
+			return "", ErrCategoryFilterUnsupported
 		}
-		b.titleCount = int(cmd.Val())
-		b.application.Logger.Info(fmt.Sprintf("found %d titles in Redis DB", b.titleCount))
+		if b.language(query.Lang) != b.application.Cfg.DefaultLanguage() {
+			return "", ErrCategoryFilterLanguage
+		}
+		b.loadCategoryMembers(ctx, query.Categories)
+		if seeded {
+			return b.application.Categories.TitleAt(ctx, query.Categories, seed)
+		}
+		return b.application.Categories.RandomTitle(ctx, query.Categories)
 	}
-	title, err := b.application.RedisClient.SRandMember(ctx, "datasource:wikipedia").Result()
+
+	titles, err := b.titles(query.Lang)
 	if err != nil {
 		return "", err
 	}
-	return title, nil
+	if seeded {
+		store, ok := titles.(repository.SeekableTitleStore)
+		if !ok {
+			return "", fmt.Errorf("title store %q does not support seeded selection", b.application.Cfg.TitleStore)
+		}
+		return store.TitleAt(ctx, seed)
+	}
+	if b.application.Cfg.TitleSelection == app.TitleSelectionPopularity {
+		if store, ok := titles.(repository.WeightedTitleStore); ok {
+			title, err := store.WeightedRandom(ctx)
+			if !errors.Is(err, repository.ErrNotFound) {
+				return title, err
+			}
+			// No weights have been indexed yet
+		}
+	}
+	return titles.Random(ctx)
+}
+
+const (
+	maxTries = 3
+	// maxDistinctAttempts bounds the title selections made while looking for a title
+	// not already part of the same response.
+	maxDistinctAttempts = 5
+)
+
+var errNoDistinctTitle = errors.New("no distinct title available")
+
+// ArticleError reports a failure to resolve the article of a selected title.
+type ArticleError struct {
+	Title string
+	Err   error
+}
+
+func (e *ArticleError) Error() string {
+	return fmt.Sprintf("article %q: %s", e.Title, e.Err)
+}
+
+func (e *ArticleError) Unwrap() error {
+	return e.Err
 }
 
-const maxTries = 2
+func randomizeArticle(ctx context.Context, triviaBackend *RandomTriviaBackend, query TitleQuery) ([]WikiSummary, error) {
+	summary, err := randomArticle(ctx, triviaBackend, query, func(string) bool { return true })
+	if err != nil {
+		return nil, err
+	}
+	return []WikiSummary{summary}, nil
+}
 
-func randomizeArticle(ctx context.Context, triviaBackend *RandomTriviaBackend) ([]WikiSummary, error) {
+// randomizeArticles resolves count distinct random articles concurrently, including any prefetched ones.
+// Articles that fail to resolve are reported individually; an error is returned
+// when title selection fails or no article could be resolved at all.
+func randomizeArticles(
+	ctx context.Context,
+	triviaBackend *RandomTriviaBackend,
+	query TitleQuery,
+	count int,
+	prefetched []WikiSummary,
+) ([]WikiSummary, []TriviaError, error) {
 	var (
-		summaryResp wikipedia.RestV1SummaryResponse
-		categories  []string
+		mu       sync.Mutex
+		failures []TriviaError
+		lastErr  error
 	)
-	for iter := 0; iter < maxTries; iter++ {
-		subj, err := triviaBackend.RandomTitle(ctx)
-		if err != nil {
-			return nil, err
+	resolved := make([]*WikiSummary, count)
+	claimed := make(map[string]struct{}, count)
+	for i, summary := range prefetched {
+		resolved[i] = &summary
+		claimed[titleKey(summary.Title)] = struct{}{}
+	}
+	claim := func(title string) bool {
+		mu.Lock()
+		defer mu.Unlock()
+		key := titleKey(title)
+		if _, ok := claimed[key]; ok {
+			return false
 		}
-		client := wikipedia.NewClient()
+		claimed[key] = struct{}{}
+		return true
+	}
 
-		group, ctx := errgroup.WithContext(ctx)
+	group, groupCtx := errgroup.WithContext(ctx)
+	group.SetLimit(max(triviaBackend.application.Cfg.TriviaConcurrency, 1))
+	for i := len(prefetched); i < count; i++ {
+		slotQuery := query
+		slotQuery.Index += i
 		group.Go(func() error {
-			summary, err := client.GetSummary(ctx, subj)
+			summary, err := randomArticle(groupCtx, triviaBackend, slotQuery, claim)
+			mu.Lock()
+			defer mu.Unlock()
 			if err != nil {
-				return err
+				var articleErr *ArticleError
+				if !errors.As(err, &articleErr) {
+					return err
+				}
+				lastErr = err
+				failures = append(failures, newTriviaError(articleErr))
+				return nil
 			}
-			summaryResp = summary
+			resolved[i] = &summary
 			return nil
 		})
-		group.Go(func() error {
-			var err error
-			categories, err = client.Categories(ctx, subj)
-			return err
-		})
-		if err := group.Wait(); err != nil {
-			if errors.Is(err, wikipedia.ErrNotFound) && iter < maxTries-1 {
+	}
+	if err := group.Wait(); err != nil {
+		return nil, nil, err
+	}
+	// Results keep their slot order, so that seeded requests are reproducible
+	var summaries []WikiSummary
+	for _, summary := range resolved {
+		if summary != nil {
+			summaries = append(summaries, *summary)
+		}
+	}
+	if len(summaries) == 0 {
+		return nil, nil, lastErr
+	}
+	return summaries, failures, nil
+}
+
+// randomArticle selects a random title accepted by claim and resolves its article,
+// selecting another title when the page does not exist.
+func randomArticle(
+	ctx context.Context,
+	triviaBackend *RandomTriviaBackend,
+	query TitleQuery,
+	claim func(string) bool,
+) (WikiSummary, error) {
+	var lastErr error
+	for iter := 0; iter < maxTries; iter++ {
+		query.attempt = iter * maxDistinctAttempts
+		subj, err := randomDistinctTitle(ctx, triviaBackend, query, claim)
+		if err != nil {
+			return WikiSummary{}, err
+		}
+		summary, err := fetchArticle(ctx, triviaBackend, query.Lang, subj)
+		var expanded bool
+		if err == nil {
+			expanded = summary.Type == articleTypeDisambiguation
+			summary, err = applyPagePolicy(ctx, triviaBackend, query, subj, summary)
+		}
+		if err == nil {
+			if reason := rejectArticle(triviaBackend.filters, summary); reason != "" {
+				// An expanded disambiguation page was not filtered itself, only the candidate it was replaced with
+				if !expanded {
+					if err := triviaBackend.Reject(ctx, query.Lang, subj, reason); err != nil {
+						app.LoggerFromContext(ctx).Warn("recording rejected title failed",
+							zap.Error(err), zap.String("title", subj))
+					}
+				}
+				err = fmt.Errorf("%w: %s", errSkippedPage, reason)
+			}
+		}
+		if err != nil {
+			lastErr = &ArticleError{Title: subj, Err: err}
+			if query.Session != "" {
+				triviaBackend.forgetForSession(ctx, query.Session, query.Lang, subj)
+			}
+			if iter < maxTries-1 {
 				logger := app.LoggerFromContext(ctx)
-				logger.Info("page not found - retrying", zap.String("title", subj))
-				continue
+				switch {
+				case errors.Is(err, wikipedia.ErrNotFound):
+					logger.Info("page not found - retrying", zap.String("title", subj))
+					continue
+				case errors.Is(err, errSkippedPage):
+					logger.Info("page skipped - retrying", zap.String("title", subj))
+					continue
+				}
 			}
-			return nil, err
+			return WikiSummary{}, lastErr
 		}
-		break
+		return summary, nil
 	}
-
-	var summaries []WikiSummary
-	summaries = append(summaries,
-		WikiSummary{
-			Title:   summaryResp.Title,
-			Summary: summaryResp.Extract,
-			Metadata: WikiSummaryMetadata{
-				Description: summaryResp.Description,
-				URL:         summaryResp.ContentUrls.Desktop.Page,
-				Image: Image{
-					URL:    summaryResp.Thumbnail.Source,
-					Width:  summaryResp.Thumbnail.Width,
-					Height: summaryResp.Thumbnail.Height,
-				},
-			},
-			Categories: categories,
-		},
-	)
-	return summaries, nil
+	return WikiSummary{}, lastErr
 }
 
-const numericIDSequence = "wikipedia_titles_numeric_id_seq"
-
-func fetchRandomArticleTitle(ctx context.Context, triviaBackend *RandomTriviaBackend) (string, error) {
-	tx := triviaBackend.application.PostgresConnection.WithContext(ctx)
-	var n int64
-	err := tx.Raw(fmt.Sprintf("SELECT LAST_VALUE FROM %s", numericIDSequence)).Scan(&n).Error
-	if err != nil {
-		return "", err
+func randomDistinctTitle(
+	ctx context.Context,
+	triviaBackend *RandomTriviaBackend,
+	query TitleQuery,
+	claim func(string) bool,
+) (string, error) {
+	for i := 0; i < maxDistinctAttempts; i++ {
+		query.attempt++
+		title, err := triviaBackend.RandomTitle(ctx, query)
+		if err != nil {
+			return "", err
+		}
+		if !claim(title) {
+			continue
+		}
+		if query.Session != "" {
+			claimed, err := triviaBackend.claimForSession(ctx, query.Session, query.Lang, title)
+			if err != nil {
+				return "", err
+			}
+			if !claimed {
+				continue
+			}
+		}
+		return title, nil
 	}
-	bucket := rand.Intn(int(n)) + 1
-	title := database.WikipediaTitle{}
-	err = tx.Clauses(clause.OrderBy{
-		Expression: clause.Expr{SQL: "RANDOM()"},
-	}).First(&title, "numeric_id = ?", bucket).Error
-	if err != nil {
-		return "", err
+	if query.Session != "" {
+		// Random picks keep hitting seen titles, as the session is close to exhausting them
+		return triviaBackend.unseenTitle(ctx, query, claim)
+	}
+	return "", &ArticleError{Err: errNoDistinctTitle}
+}
+
+func newTriviaError(err *ArticleError) TriviaError {
+	msg := "lookup failed"
+	switch {
+	case errors.Is(err, wikipedia.ErrNotFound):
+		msg = "not found"
+	case errors.Is(err, errNoDistinctTitle):
+		msg = errNoDistinctTitle.Error()
+	case errors.Is(err, errSkippedPage):
+		msg = "skipped"
 	}
-	return title.Title, nil
+	return TriviaError{Title: err.Title, Error: msg}
+}
+
+// titleKey returns the form of a title used to detect duplicates.
+func titleKey(title string) string {
+	return strings.ReplaceAll(title, "_", " ")
 }